@@ -25,6 +25,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"regexp"
 	"strconv"
@@ -51,11 +52,15 @@ var encoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopq
 const saltLen = 16
 
 type hashed struct {
+	format  Format
 	time    uint32
 	memory  uint32
 	threads uint8
 	hash    []byte
 	salt    []byte
+
+	// prefix is the scheme prefix as it was read, when it differs in case from the one the format writes
+	prefix string
 }
 
 // t=1 is recommended for Argon2id variant (https://tools.ietf.org/html/draft-irtf-cfrg-argon2-03#section-9.4)
@@ -69,11 +74,12 @@ const defaultThreads uint8 = 4
 
 const defaultKeyLen uint32 = 32
 
-var rx = regexp.MustCompile(`^\$argon2id([0-9]{1,4})\$([0-9]{1,10}),([0-9]{1,10}),([0-9]{1,3})\$([./a-zA-Z0-9]+)\$([./a-zA-Z0-9]+)$`)
+var rx = regexp.MustCompile(`^\$argon2id([0-9]{1,4})\$([0-9]{1,10}),([0-9]{1,10}),([0-9]{1,3})\$([./+a-zA-Z0-9]+)\$([./+a-zA-Z0-9]+)$`)
 
-// IsHashedPassword will return true if hashedPassword is a proper password hashed by this library
+// IsHashedPassword will return true if hashedPassword looks like a hash in one of the supported formats, including
+// hashes from earlier releases that used the standard base64 alphabet
 func IsHashedPassword(hashedPassword string) bool {
-	return rx.MatchString(hashedPassword)
+	return DetectFormat(hashedPassword) != FormatUnknown
 }
 
 // DefaultHashPassword is a convenience function that calls HashPassword() with default values
@@ -83,47 +89,131 @@ func DefaultHashPassword(password string) (string, error) {
 
 // HashPassword will hash the password. If time, memory, threads or keyLen is "0", then a sane default will be used.
 func HashPassword(password string, time, memory uint32, threads uint8, keyLen uint32) (string, error) {
-	if time == 0 {
-		time = defaultTime
-	}
+	return Hasher{Params: Params{Time: time, Memory: memory, Threads: threads, KeyLen: keyLen}}.Hash(password)
+}
 
-	if memory == 0 {
-		memory = defaultMemory
-	}
+// HashPasswordBytes is HashPassword for a password held in a byte slice, which is not copied
+func HashPasswordBytes(password []byte, time, memory uint32, threads uint8, keyLen uint32) (string, error) {
+	return Hasher{Params: Params{Time: time, Memory: memory, Threads: threads, KeyLen: keyLen}}.HashBytes(password)
+}
 
-	if threads == 0 {
-		threads = defaultThreads
+// Compare will compare the hashedPassword with the supplied password. If unsuccessful, an error will be returned. On success, error is nil.
+func Compare(hashedPassword, password string) error {
+	pw := []byte(password)
+	defer wipe(pw)
+	return CompareBytes(hashedPassword, pw)
+}
+
+// CompareBytes is Compare for a password held in a byte slice, which is not copied. The caller may wipe it afterwards.
+func CompareBytes(hashedPassword string, password []byte) error {
+	start := now()
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		observe(OperationCompare, start, Params{}, DetectFormat(hashedPassword), err)
+		return err
 	}
 
-	if keyLen == 0 {
-		keyLen = defaultKeyLen
+	defer h.wipe()
+	err = h.compare(password)
+	observe(OperationCompare, start, h.params(), h.format, err)
+	return err
+}
+
+func newHashed(format Format, p Params, salt []byte) *hashed {
+	return &hashed{
+		format:  format,
+		time:    p.Time,
+		memory:  p.Memory,
+		threads: p.Threads,
+		salt:    salt,
 	}
+}
 
-	salt, err := generateSalt()
-	if err != nil {
-		return "", err
+func encodeSynacor(h *hashed) string {
+	enc := encoding
+	if h.format == FormatSynacorStd {
+		enc = stdEncoding
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, time, memory, threads, keyLen)
-	return fmt.Sprintf("$argon2id%d$%d,%d,%d$%s$%s", argon2.Version, time, memory, threads, encoding.EncodeToString(salt), encoding.EncodeToString(hash)), nil
+	return fmt.Sprintf("$argon2id%d$%d,%d,%d$%s$%s", argon2.Version, h.time, h.memory, h.threads, enc.EncodeToString(h.salt), enc.EncodeToString(h.hash))
 }
 
-// Compare will compare the hashedPassword with the supplied password. If unsuccessful, an error will be returned. On success, error is nil.
-func Compare(hashedPassword, password string) error {
-	h, err := newHashedFromHashedPassword(hashedPassword)
-	if err != nil {
-		return err
+// compare will check the password against the hash. If the alphabet of the hash could not be told apart, it may be a
+// hash from an earlier release after all, so both candidates are computed every time; stopping at the first match
+// would take half as long for hashes in the current alphabet as for the others and for mismatches.
+func (h *hashed) compare(password []byte) error {
+	alt := h.legacyAlternative()
+	matched := h.matches(password)
+	if alt == nil {
+		if matched {
+			return nil
+		}
+		return ErrMismatchedHashAndPassword
 	}
 
-	compareHash := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.hash)))
-	if subtle.ConstantTimeCompare(h.hash, compareHash) == 1 {
+	altMatched := alt.matches(password)
+	if !matched && altMatched {
+		wipe(h.hash)
+		*h = *alt
 		return nil
 	}
 
+	alt.wipe()
+	if matched {
+		return nil
+	}
 	return ErrMismatchedHashAndPassword
 }
 
-func newHashedFromHashedPassword(hashedPassword string) (*hashed, error) {
+func (h *hashed) matches(password []byte) bool {
+	compareHash := deriveKey(password, h.salt, h.params(), nil)
+	defer wipe(compareHash)
+	return subtle.ConstantTimeCompare(h.hash, compareHash) == 1
+}
+
+// wipe will zero the raw hash once it is no longer needed. Its length, the key length, is kept.
+func (h *hashed) wipe() {
+	wipe(h.hash)
+}
+
+// wipe will overwrite b with zeros. It is best effort: copies made by the garbage collector or by callers are out of
+// reach.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// deriveKey runs Argon2id, letting an InFlightObserver know when it starts and ends. progress may be nil.
+func deriveKey(password, salt []byte, p Params, progress ProgressFunc) []byte {
+	if o, ok := currentObserver().(InFlightObserver); ok {
+		o.Begin(p)
+		defer o.End(p)
+	}
+
+	return currentCompute().idKey(password, salt, p, progress)
+}
+
+func matchSynacor(hashedPassword string) bool {
+	const prefix = "$argon2id"
+	return len(hashedPassword) > len(prefix) && hashedPassword[:len(prefix)] == prefix && hashedPassword[len(prefix)] >= '0' && hashedPassword[len(prefix)] <= '9'
+}
+
+func detectSynacor(hashedPassword string) Format {
+	match := rx.FindStringSubmatch(hashedPassword)
+	if match == nil {
+		return FormatUnknown
+	}
+
+	format, _, _, err := decodeSaltAndHash(match[5], match[6])
+	if err != nil {
+		return FormatUnknown
+	}
+
+	return format
+}
+
+func newHashedFromSynacor(hashedPassword string) (*hashed, error) {
 	match := rx.FindStringSubmatch(hashedPassword)
 	if match == nil {
 		return nil, ErrInvalidHash
@@ -140,22 +230,17 @@ func newHashedFromHashedPassword(hashedPassword string) (*hashed, error) {
 		return nil, ErrInvalidArgon2Version
 	}
 
-	rawHash, err := encoding.DecodeString(hash)
+	format, rawSalt, rawHash, err := decodeSaltAndHash(salt, hash)
 	if err != nil {
 		return nil, err
 	}
 
-	rawSalt, err := encoding.DecodeString(salt)
-	if err != nil {
-		return nil, err
-	}
-
-	// prevent overflow errors
-	if time == 0 || time > math.MaxUint32 || memory > math.MaxUint32 || threads == 0 || threads > math.MaxUint8 {
+	if !validComplexity(uint64(time), uint64(memory), uint64(threads)) {
 		return nil, ErrInvalidComplexity
 	}
 
 	return &hashed{
+		format:  format,
 		time:    uint32(time),
 		memory:  uint32(memory),
 		threads: uint8(threads),
@@ -164,9 +249,14 @@ func newHashedFromHashedPassword(hashedPassword string) (*hashed, error) {
 	}, nil
 }
 
+// validComplexity checks the bounds of the parsed values to prevent overflow errors
+func validComplexity(time, memory, threads uint64) bool {
+	return time != 0 && time <= math.MaxUint32 && memory <= math.MaxUint32 && threads != 0 && threads <= math.MaxUint8
+}
+
 func generateSalt() ([]byte, error) {
 	salt := make([]byte, saltLen)
-	_, err := rand.Read(salt)
+	_, err := io.ReadFull(rand.Reader, salt)
 	if err != nil {
 		return nil, err
 	}
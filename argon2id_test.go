@@ -96,3 +96,32 @@ func TestFailure(t *testing.T) {
 	g.Expect(Compare("$argon2id19$1,65536,0$Oy7MSyjRTCTMTAzROSvSGO$QV5KGjrKZO6C.8St0T7HCTL0AvuCxgf5O.Okwj90a3a", "threads too low")).Should(gomega.Equal(ErrInvalidComplexity))
 	g.Expect(Compare("$argon2id19$1,65536,256$Oy7MSyjRTCTMTAzROSvSGO$QV5KGjrKZO6C.8St0T7HCTL0AvuCxgf5O.Okwj90a3a", "threads too large")).Should(gomega.Equal(ErrInvalidComplexity))
 }
+
+func TestPasswordBytes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	password := []byte("my-password")
+	hashedPassword, err := HashPasswordBytes(password, 1, 1024, 1, 32)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(password).Should(gomega.Equal([]byte("my-password")))
+
+	g.Expect(CompareBytes(hashedPassword, password)).Should(gomega.Succeed())
+	g.Expect(Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+	g.Expect(CompareBytes(hashedPassword, []byte("bad-password"))).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(CompareBytes("bad-hash", password)).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(password).Should(gomega.Equal([]byte("my-password")))
+
+	// the raw hash is wiped after use, but only once it has been encoded or compared
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h.compare(password)).Should(gomega.Succeed())
+	h.wipe()
+	g.Expect(h.hash).Should(gomega.Equal(make([]byte, 32)))
+	g.Expect(h.params().KeyLen).Should(gomega.Equal(uint32(32)))
+
+	setting, err := GenSalt(Params{Memory: 1024})
+	g.Expect(err).Should(gomega.Succeed())
+	crypted, err := Crypt("my-password", setting)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(CryptBytes(password, setting)).Should(gomega.Equal(crypted))
+}
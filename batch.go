@@ -0,0 +1,173 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/synacor/argon2id/internal/semaphore"
+)
+
+// Pair is a hashed password and the password that should be compared against it.
+type Pair struct {
+	HashedPassword string
+	Password       string
+}
+
+// BatchResult is the outcome of comparing a single Pair. Index is the position of the Pair in the input.
+type BatchResult struct {
+	Index int
+	Err   error
+}
+
+// BatchOptions controls how CompareBatch and CompareStream schedule comparisons.
+type BatchOptions struct {
+	// Workers is the maximum number of comparisons that run at once. If "0", runtime.NumCPU() is used.
+	Workers int
+
+	// MemoryBudget is the maximum amount of Argon2 memory, in KiB, that may be in use at once. A hash that needs more
+	// than the whole budget will run by itself. If "0", memory is only bounded by Workers.
+	MemoryBudget uint64
+}
+
+func (o BatchOptions) workers() int {
+	if o.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+
+	return o.Workers
+}
+
+// CompareBatch will compare every Pair and return one error per Pair, in the same order as pairs. A nil entry means
+// the password matched. If ctx is cancelled, the comparisons that did not finish get ctx.Err() and ctx.Err() is also
+// returned as the second value.
+func CompareBatch(ctx context.Context, pairs []Pair, opts BatchOptions) ([]error, error) {
+	in := make(chan Pair)
+	go func() {
+		defer close(in)
+		for _, pair := range pairs {
+			select {
+			case in <- pair:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	errs := make([]error, len(pairs))
+	finished := make([]bool, len(pairs))
+	for result := range CompareStream(ctx, in, opts) {
+		errs[result.Index] = result.Err
+		finished[result.Index] = result.Err == nil || result.Err != ctx.Err()
+	}
+
+	var err error
+	for i := range finished {
+		if !finished[i] {
+			errs[i] = ctx.Err()
+			err = ctx.Err()
+		}
+	}
+
+	return errs, err
+}
+
+// CompareStream will compare each Pair read from pairs and send a BatchResult for it on the returned channel, in the
+// order the pairs were received. The returned channel is closed once pairs is closed and every comparison has
+// finished, or once ctx is cancelled and the comparisons already started have reported. The caller must keep reading
+// from the returned channel until it is closed.
+func CompareStream(ctx context.Context, pairs <-chan Pair, opts BatchOptions) <-chan BatchResult {
+	workers := opts.workers()
+
+	var budget *semaphore.Weighted
+	if opts.MemoryBudget > 0 {
+		budget = semaphore.New(opts.MemoryBudget)
+	}
+
+	results := make(chan BatchResult, workers)
+	pending := make(chan chan BatchResult, workers)
+	tokens := make(chan struct{}, workers)
+
+	// results are handed to the emitter in input order, so it only has to wait on each one in turn
+	go func() {
+		defer close(results)
+		for done := range pending {
+			results <- <-done
+		}
+	}()
+
+	go func() {
+		defer close(pending)
+		for index := 0; ctx.Err() == nil; index++ {
+			var pair Pair
+			var ok bool
+			select {
+			case pair, ok = <-pairs:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			done := make(chan BatchResult, 1)
+			pending <- done
+
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				done <- BatchResult{Index: index, Err: ctx.Err()}
+				return
+			}
+
+			go func(index int, pair Pair) {
+				defer func() { <-tokens }()
+				done <- BatchResult{Index: index, Err: compareWithBudget(ctx, budget, pair)}
+			}(index, pair)
+		}
+	}()
+
+	return results
+}
+
+func compareWithBudget(ctx context.Context, budget *semaphore.Weighted, pair Pair) error {
+	h, err := newHashedFromHashedPassword(pair.HashedPassword)
+	if err != nil {
+		observe(OperationCompare, now(), Params{}, DetectFormat(pair.HashedPassword), err)
+		return err
+	}
+
+	if budget != nil {
+		n := budget.Clamp(uint64(h.memory))
+		if err := budget.Acquire(ctx, n); err != nil {
+			return err
+		}
+		defer budget.Release(n)
+	}
+
+	// the time spent waiting for the memory budget is not part of the comparison
+	start := now()
+	pw := []byte(pair.Password)
+	defer wipe(pw)
+	defer h.wipe()
+	err = h.compare(pw)
+	observe(OperationCompare, start, h.params(), h.format, err)
+	return err
+}
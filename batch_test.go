@@ -0,0 +1,94 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCompareBatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h1, _ := HashPassword("one", 1, 1024, 1, 16)
+	h2, _ := HashPassword("two", 1, 2048, 2, 16)
+
+	pairs := []Pair{
+		{HashedPassword: h1, Password: "one"},
+		{HashedPassword: h2, Password: "bad-password"},
+		{HashedPassword: "bad-hash", Password: "three"},
+		{HashedPassword: h2, Password: "two"},
+	}
+
+	errs, err := CompareBatch(context.Background(), pairs, BatchOptions{Workers: 2, MemoryBudget: 2048})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(errs).Should(gomega.Equal([]error{nil, ErrMismatchedHashAndPassword, ErrInvalidHash, nil}))
+
+	// a budget smaller than a single hash still lets it run
+	errs, err = CompareBatch(context.Background(), pairs[:1], BatchOptions{MemoryBudget: 1})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(errs).Should(gomega.Equal([]error{nil}))
+}
+
+func TestCompareBatchCancelled(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, _ := HashPassword("one", 1, 1024, 1, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errs, err := CompareBatch(ctx, []Pair{{HashedPassword: h, Password: "one"}, {HashedPassword: h, Password: "one"}}, BatchOptions{})
+	g.Expect(err).Should(gomega.Equal(context.Canceled))
+	g.Expect(errs).Should(gomega.Equal([]error{context.Canceled, context.Canceled}))
+}
+
+func TestCompareStream(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, _ := HashPassword("stream", 1, 1024, 1, 16)
+
+	in := make(chan Pair)
+	go func() {
+		defer close(in)
+		for i := 0; i < 10; i++ {
+			password := "stream"
+			if i%2 == 1 {
+				password = "bad-password"
+			}
+			in <- Pair{HashedPassword: h, Password: password}
+		}
+	}()
+
+	var results []BatchResult
+	for result := range CompareStream(context.Background(), in, BatchOptions{Workers: 3, MemoryBudget: 2048}) {
+		results = append(results, result)
+	}
+
+	g.Expect(results).Should(gomega.HaveLen(10))
+	for i, result := range results {
+		g.Expect(result.Index).Should(gomega.Equal(i))
+		if i%2 == 1 {
+			g.Expect(result.Err).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+		} else {
+			g.Expect(result.Err).Should(gomega.Succeed())
+		}
+	}
+}
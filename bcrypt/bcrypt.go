@@ -0,0 +1,185 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package bcrypt mirrors the API of golang.org/x/crypto/bcrypt with argon2id underneath, so that code using bcrypt can
+// switch by changing its import path. The cost is mapped to tiers of parameters: DefaultCost uses the package's
+// built-in defaults of 64 MiB, t=1 and 4 threads, each lower cost halves the memory and each higher cost doubles the
+// passes. As with bcrypt, every step up doubles the work.
+//
+// Errors keep bcrypt's semantics: ErrMismatchedHashAndPassword for a wrong password, ErrHashTooShort and
+// InvalidHashPrefixError for input that cannot be a hash at all, and an error describing the problem for a hash that
+// cannot be used. Hashes that were not made by this package, in any format the argon2id package reads, are verified
+// as well.
+package bcrypt
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// The costs accepted by GenerateFromPassword, as in bcrypt
+const (
+	MinCost     int = 4
+	MaxCost     int = 31
+	DefaultCost int = 10
+)
+
+// The tier of DefaultCost
+const (
+	defaultMemory  uint32 = 64 * 1024
+	defaultThreads uint8  = 4
+	keyLen         uint32 = 32
+)
+
+// ErrMismatchedHashAndPassword is returned by CompareHashAndPassword when the password is not the hashed password. It
+// is the argon2id package's error, so it can be compared against either.
+var ErrMismatchedHashAndPassword = argon2id.ErrMismatchedHashAndPassword
+
+// ErrInvalidHash is returned when the hashed password is not a hash in a supported format
+var ErrInvalidHash = argon2id.ErrInvalidHash
+
+// ErrInvalidArgon2Version is returned for hashes of an argon2 version that cannot be verified, where bcrypt would
+// return a HashVersionTooNewError
+var ErrInvalidArgon2Version = argon2id.ErrInvalidArgon2Version
+
+// ErrHashTooShort is returned when the hashed password is shorter than any hash in a supported format
+var ErrHashTooShort = errors.New("synacor/argon2id: hashedSecret too short to be a hashed password")
+
+// ErrPasswordTooLong is bcrypt's error for passwords over 72 bytes. Argon2 has no such limit, so it is never returned;
+// it is defined so that code naming it still compiles.
+var ErrPasswordTooLong = errors.New("synacor/argon2id: password length exceeds 72 bytes")
+
+// HashVersionTooNewError is bcrypt's error for a hash of a newer bcrypt version. Argon2 versions are checked by the
+// argon2id package instead, which returns ErrInvalidArgon2Version, so it is never returned; it is defined so that code
+// naming it still compiles.
+type HashVersionTooNewError byte
+
+func (hv HashVersionTooNewError) Error() string {
+	return fmt.Sprintf("synacor/argon2id: hash version '%c' requested is newer than current version", byte(hv))
+}
+
+// InvalidHashPrefixError is returned when the hashed password starts with a character that no supported format
+// starts with
+type InvalidHashPrefixError byte
+
+func (ih InvalidHashPrefixError) Error() string {
+	return fmt.Sprintf("synacor/argon2id: hashes must start with '$', '{' or \"argon2$\", but hashedSecret started with '%c'", byte(ih))
+}
+
+// minHashSize is the length of "$argon2id19$", the shortest start of a hash in any supported format
+const minHashSize = 12
+
+// checkHash will return bcrypt's errors for input that cannot be a hashed password in any format
+func checkHash(hashedPassword []byte) error {
+	if len(hashedPassword) < minHashSize {
+		return ErrHashTooShort
+	}
+
+	switch {
+	case hashedPassword[0] == '$', hashedPassword[0] == '{', strings.HasPrefix(string(hashedPassword), "argon2$"):
+		return nil
+	}
+
+	return InvalidHashPrefixError(hashedPassword[0])
+}
+
+// InvalidCostError is returned by GenerateFromPassword when the cost is above MaxCost
+type InvalidCostError int
+
+func (ic InvalidCostError) Error() string {
+	return fmt.Sprintf("synacor/argon2id: cost %d is outside allowed range (%d,%d)", int(ic), MinCost, MaxCost)
+}
+
+// GenerateFromPassword will return the hash of the password at the given cost. A cost below MinCost is replaced by
+// DefaultCost, as bcrypt does.
+func GenerateFromPassword(password []byte, cost int) ([]byte, error) {
+	if cost < MinCost {
+		cost = DefaultCost
+	}
+
+	if cost > MaxCost {
+		return nil, InvalidCostError(cost)
+	}
+
+	hashedPassword, err := argon2id.Hasher{Params: costParams(cost)}.HashBytes(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(hashedPassword), nil
+}
+
+// CompareHashAndPassword will return nil if password is the hashed password, or an error otherwise
+func CompareHashAndPassword(hashedPassword, password []byte) error {
+	if err := checkHash(hashedPassword); err != nil {
+		return err
+	}
+
+	return argon2id.CompareBytes(string(hashedPassword), password)
+}
+
+// Cost will return the cost the hashed password was created with. For hashes that do not match a tier exactly, such
+// as those made with other parameters, it is the cost of the nearest tier with no more work.
+func Cost(hashedPassword []byte) (int, error) {
+	if err := checkHash(hashedPassword); err != nil {
+		return 0, err
+	}
+
+	p, err := argon2id.GetParams(string(hashedPassword))
+	if err != nil {
+		return 0, err
+	}
+
+	return paramsCost(p), nil
+}
+
+// costParams will return the parameters of a tier
+func costParams(cost int) argon2id.Params {
+	p := argon2id.Params{Time: 1, Memory: defaultMemory, Threads: defaultThreads, KeyLen: keyLen}
+	if cost < DefaultCost {
+		p.Memory >>= uint(DefaultCost - cost)
+	} else {
+		p.Time <<= uint(cost - DefaultCost)
+	}
+
+	return p
+}
+
+// paramsCost will return the highest cost whose work, memory times passes, is not above that of p
+func paramsCost(p argon2id.Params) int {
+	work := uint64(p.Memory) * uint64(p.Time)
+	if work == 0 {
+		return MinCost
+	}
+
+	// each cost doubles the work, so the cost follows the base 2 logarithm of the work
+	log2 := func(n uint64) int { return bits.Len64(n) - 1 }
+	cost := DefaultCost + log2(work) - log2(uint64(defaultMemory))
+	if cost < MinCost {
+		cost = MinCost
+	}
+	if cost > MaxCost {
+		cost = MaxCost
+	}
+
+	return cost
+}
@@ -0,0 +1,99 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package bcrypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestGenerateFromPassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hashedPassword, err := GenerateFromPassword([]byte("my-password"), MinCost)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(hashedPassword)).Should(gomega.HavePrefix("$argon2id19$1,1024,4$"))
+	g.Expect(CompareHashAndPassword(hashedPassword, []byte("my-password"))).Should(gomega.Succeed())
+	g.Expect(CompareHashAndPassword(hashedPassword, []byte("bad-password"))).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(CompareHashAndPassword(hashedPassword, []byte("bad-password"))).Should(gomega.Equal(argon2id.ErrMismatchedHashAndPassword))
+	g.Expect(Cost(hashedPassword)).Should(gomega.Equal(MinCost))
+
+	_, err = GenerateFromPassword([]byte("my-password"), MaxCost+1)
+	g.Expect(err).Should(gomega.Equal(InvalidCostError(32)))
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: cost 32 is outside allowed range (4,31)"))
+
+	g.Expect(CompareHashAndPassword([]byte("$2a$10$bad-hash-here"), []byte("my-password"))).Should(gomega.Equal(ErrInvalidHash))
+}
+
+func TestHashErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(CompareHashAndPassword(nil, []byte("my-password"))).Should(gomega.Equal(ErrHashTooShort))
+	g.Expect(CompareHashAndPassword([]byte("$2a$10$bad"), []byte("my-password"))).Should(gomega.Equal(ErrHashTooShort))
+	_, err := Cost([]byte(""))
+	g.Expect(err).Should(gomega.Equal(ErrHashTooShort))
+
+	err = CompareHashAndPassword([]byte("argon2id19$1,1024,4$"), []byte("my-password"))
+	g.Expect(err).Should(gomega.Equal(InvalidHashPrefixError('a')))
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: hashes must start with '$', '{' or "argon2$", but hashedSecret started with 'a'`))
+	_, err = Cost([]byte("*argon2id19$1,1024,4$"))
+	g.Expect(err).Should(gomega.Equal(InvalidHashPrefixError('*')))
+
+	// the other prefixes of supported formats pass the check
+	for _, h := range []string{"{ARGON2}$argon2id$", "argon2$argon2id$v=19$"} {
+		g.Expect(checkHash([]byte(h))).Should(gomega.Succeed())
+	}
+
+	// defined for compatibility with golang.org/x/crypto/bcrypt
+	g.Expect(ErrPasswordTooLong).Should(gomega.MatchError("synacor/argon2id: password length exceeds 72 bytes"))
+	g.Expect(HashVersionTooNewError('3')).Should(gomega.MatchError("synacor/argon2id: hash version '3' requested is newer than current version"))
+	_, err = GenerateFromPassword([]byte(strings.Repeat("x", 100)), MinCost)
+	g.Expect(err).Should(gomega.Succeed())
+}
+
+func TestCostTiers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(costParams(DefaultCost)).Should(gomega.Equal(argon2id.DefaultParams()))
+	g.Expect(costParams(MinCost)).Should(gomega.Equal(argon2id.Params{Time: 1, Memory: 1024, Threads: 4, KeyLen: 32}))
+	g.Expect(costParams(12)).Should(gomega.Equal(argon2id.Params{Time: 4, Memory: 64 * 1024, Threads: 4, KeyLen: 32}))
+	g.Expect(costParams(MaxCost)).Should(gomega.Equal(argon2id.Params{Time: 1 << 21, Memory: 64 * 1024, Threads: 4, KeyLen: 32}))
+
+	for cost := MinCost; cost <= MaxCost; cost++ {
+		g.Expect(paramsCost(costParams(cost))).Should(gomega.Equal(cost))
+	}
+
+	// other parameters get the tier with no more work
+	g.Expect(paramsCost(argon2id.Params{Time: 3, Memory: 64 * 1024})).Should(gomega.Equal(11))
+	g.Expect(paramsCost(argon2id.RFC9106FirstRecommended)).Should(gomega.Equal(15))
+	g.Expect(paramsCost(argon2id.Params{Time: 1, Memory: 8})).Should(gomega.Equal(MinCost))
+}
+
+func TestCostOfOtherFormats(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the reference PHC hash, with t=2 and 64 MiB
+	phc := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	g.Expect(Cost([]byte(phc))).Should(gomega.Equal(11))
+	g.Expect(CompareHashAndPassword([]byte(phc), []byte("password"))).Should(gomega.Succeed())
+	g.Expect(CompareHashAndPassword([]byte(strings.Replace(phc, "v=19", "v=16", 1)), []byte("password"))).Should(gomega.Equal(ErrInvalidArgon2Version))
+}
@@ -0,0 +1,219 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// binaryLayoutVersion is the first byte of every binary encoded hash. It changes whenever the layout does.
+const binaryLayoutVersion = 1
+
+// binaryLayoutPrefixed is layout 1 with the scheme prefix of the string form after the variant. It is only written
+// for hashes whose prefix differs in case from the one their format writes, so other hashes keep layout 1.
+const binaryLayoutPrefixed = 2
+
+// binaryFormatCodes identify the Format of the string form in the binary layout. The codes are part of the layout
+// and do not follow the order of the Format constants: a code is never changed or reused, and a new format takes the
+// next unused one.
+var binaryFormatCodes = map[Format]byte{
+	FormatSynacor:    1,
+	FormatSynacorStd: 2,
+	FormatPHC:        3,
+	FormatLDAP:       4,
+	FormatDovecot:    5,
+	FormatDjango:     6,
+	FormatSpring:     7,
+	FormatSpringV58:  8,
+}
+
+// formatFromBinaryCode will return the Format with the given code, or FormatUnknown if there is none
+func formatFromBinaryCode(code byte) Format {
+	for f, c := range binaryFormatCodes {
+		if c == code {
+			return f
+		}
+	}
+
+	return FormatUnknown
+}
+
+// variantArgon2id is the Argon2 type identifier for Argon2id (https://tools.ietf.org/html/rfc9106#section-3.1)
+const variantArgon2id = 2
+
+// MarshalBinary implements encoding.BinaryMarshaler. The layout is:
+//
+//	byte     layout version (1)
+//	byte     Format of the string form, as a fixed code
+//	byte     Argon2 variant (2 for Argon2id)
+//	uvarint  scheme prefix length, followed by the prefix (layout version 2 only)
+//	uvarint  Argon2 version
+//	uvarint  time
+//	uvarint  memory
+//	uvarint  threads
+//	uvarint  salt length, followed by the salt
+//	         the rest is the hash
+//
+// Layout version 2 is written instead of 1 when the scheme prefix must be kept, such as "{argon2id}" for FormatDovecot.
+// UnmarshalBinary restores the string in the same format. Numbers and base64 are always written in their canonical
+// form, so a hash with leading zeros in its parameters comes back without them. The empty Hash is encoded as zero
+// bytes.
+func (h Hash) MarshalBinary() ([]byte, error) {
+	if h == "" {
+		return []byte{}, nil
+	}
+
+	parsed, err := newHashedFromHashedPassword(string(h))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.marshalBinary()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. See MarshalBinary for the layout. The values are validated
+// in the same way as ParseHash.
+func (h *Hash) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*h = ""
+		return nil
+	}
+
+	parsed, err := newHashedFromBinary(data)
+	if err != nil {
+		return err
+	}
+
+	*h = Hash(parsed.encode())
+	return nil
+}
+
+// CompareBinary will compare a hash encoded by Hash.MarshalBinary with the supplied password, without converting it
+// to its string form first. If unsuccessful, an error will be returned. On success, error is nil.
+func CompareBinary(data []byte, password string) error {
+	start := now()
+	h, err := newHashedFromBinary(data)
+	if err != nil {
+		observe(OperationCompare, start, Params{}, FormatUnknown, err)
+		return err
+	}
+
+	pw := []byte(password)
+	defer wipe(pw)
+	defer h.wipe()
+	err = h.compare(pw)
+	observe(OperationCompare, start, h.params(), h.format, err)
+	return err
+}
+
+func (h *hashed) marshalBinary() ([]byte, error) {
+	code, ok := binaryFormatCodes[h.format]
+	if !ok {
+		return nil, fmt.Errorf("synacor/argon2id: format %v has no binary encoding", h.format)
+	}
+
+	buf := make([]byte, 3, 3+5*binary.MaxVarintLen32+len(h.salt)+len(h.hash))
+	buf[0] = binaryLayoutVersion
+	buf[1] = code
+	buf[2] = variantArgon2id
+	if h.prefix != "" {
+		buf[0] = binaryLayoutPrefixed
+		buf = appendUvarint(buf, uint64(len(h.prefix)))
+		buf = append(buf, h.prefix...)
+	}
+
+	buf = appendUvarint(buf, argon2.Version)
+	buf = appendUvarint(buf, uint64(h.time))
+	buf = appendUvarint(buf, uint64(h.memory))
+	buf = appendUvarint(buf, uint64(h.threads))
+	buf = appendUvarint(buf, uint64(len(h.salt)))
+	buf = append(buf, h.salt...)
+	return append(buf, h.hash...), nil
+}
+
+func newHashedFromBinary(data []byte) (*hashed, error) {
+	if len(data) < 3 || (data[0] != binaryLayoutVersion && data[0] != binaryLayoutPrefixed) || data[2] != variantArgon2id {
+		return nil, ErrInvalidHash
+	}
+
+	format := formatFromBinaryCode(data[1])
+	c, ok := codecsByFormat[format]
+	if !ok {
+		return nil, ErrInvalidHash
+	}
+
+	rest := data[3:]
+	var prefix string
+	if data[0] == binaryLayoutPrefixed {
+		prefixLen, n := binary.Uvarint(rest)
+		if n <= 0 || prefixLen == 0 || prefixLen > uint64(len(rest[n:])) {
+			return nil, ErrInvalidHash
+		}
+		prefix, rest = string(rest[n:n+int(prefixLen)]), rest[n+int(prefixLen):]
+	}
+
+	// version, time, memory, threads and the salt length
+	var values [5]uint64
+	for i := range values {
+		v, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return nil, ErrInvalidHash
+		}
+		values[i], rest = v, rest[n:]
+	}
+
+	version, time, memory, threads, saltLen := values[0], values[1], values[2], values[3], values[4]
+	if saltLen == 0 || saltLen >= uint64(len(rest)) {
+		return nil, ErrInvalidHash
+	}
+
+	if version != argon2.Version {
+		return nil, ErrInvalidArgon2Version
+	}
+
+	if !validComplexity(time, memory, threads) {
+		return nil, ErrInvalidComplexity
+	}
+
+	h := &hashed{
+		format:  format,
+		time:    uint32(time),
+		memory:  uint32(memory),
+		threads: uint8(threads),
+		salt:    append([]byte(nil), rest[:saltLen]...),
+		hash:    append([]byte(nil), rest[saltLen:]...),
+		prefix:  prefix,
+	}
+
+	// the prefix must be one that the format reads back as itself
+	if prefix != "" && (c.detect == nil || c.detect(h.encode()) != format) {
+		return nil, ErrInvalidHash
+	}
+
+	return h, nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
@@ -0,0 +1,140 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestHashBinary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	data, err := Hash(testHash).MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(data[:10]).Should(gomega.Equal([]byte{1, 1, 2, 19, 1, 0x80, 0x80, 0x04, 4, 16}))
+	g.Expect(data).Should(gomega.HaveLen(10 + 16 + 32))
+
+	var h Hash
+	g.Expect(h.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash(testHash)))
+
+	g.Expect(CompareBinary(data, "my-password")).Should(gomega.Succeed())
+	g.Expect(CompareBinary(data, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	// the empty Hash
+	data, err = Hash("").MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(data).Should(gomega.BeEmpty())
+	g.Expect(h.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash("")))
+
+	// parameters are written in canonical form
+	data, err = Hash("$argon2id19$01,65536,04$fjSIS8wLOEZRF/9ceB3Ct.$YCdi8.UQsEGFBsAwVGH/U5lwlvHWLbUl7MzSXwFJ7Oy").MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash(testHash)))
+
+	// every format comes back as it went in
+	data, err = Hash(referencePHCHash).MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(data[1]).Should(gomega.Equal(byte(3)))
+	g.Expect(h.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash(referencePHCHash)))
+
+	_, err = Hash("bad-hash").MarshalBinary()
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+}
+
+func TestHashBinaryGolden(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the format codes are part of the layout and must never change
+	const params = "021301808004041086550a53ec8d4066d31c1fde803e44bc6847e4f80592b862070ee0b25c82415bb9f29f125835d5a7f4ed546721cbf50d"
+	const phc = "$argon2id$v=19$m=65536,t=1,p=4$hlUKU+yNQGbTHB/egD5EvA$aEfk+AWSuGIHDuCyXIJBW7nynxJYNdWn9O1UZyHL9Q0"
+	golden := map[Format]struct {
+		hash string
+		data string
+	}{
+		FormatSynacor:    {testHash, "0101" + params},
+		FormatSynacorStd: {"$argon2id19$1,65536,4$hlUKU+yNQGbTHB/egD5EvA$aEfk+AWSuGIHDuCyXIJBW7nynxJYNdWn9O1UZyHL9Q0", "0102" + params},
+		FormatPHC:        {phc, "0103" + params},
+		FormatLDAP:       {"{ARGON2}" + phc, "0104" + params},
+		FormatDovecot:    {"{ARGON2ID}" + phc, "0105" + params},
+		FormatDjango:     {"argon2" + phc, "0106" + params},
+		FormatSpring:     {"{argon2}" + phc, "0107" + params},
+		FormatSpringV58:  {"{argon2@SpringSecurity_v5_8}" + phc, "0108" + params},
+	}
+
+	for _, f := range Formats() {
+		expected, ok := golden[f]
+		g.Expect(ok).Should(gomega.BeTrue(), "no golden bytes for %v", f)
+
+		data, err := Hash(expected.hash).MarshalBinary()
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(hex.EncodeToString(data)).Should(gomega.Equal(expected.data), "%v", f)
+
+		raw, _ := hex.DecodeString(expected.data)
+		var h Hash
+		g.Expect(h.UnmarshalBinary(raw)).Should(gomega.Succeed())
+		g.Expect(h).Should(gomega.Equal(Hash(expected.hash)))
+	}
+}
+
+func TestHashBinaryFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	data, _ := Hash(testHash).MarshalBinary()
+	modified := func(i int, b byte) []byte {
+		d := append([]byte(nil), data...)
+		d[i] = b
+		return d
+	}
+
+	var h Hash
+	g.Expect(h.UnmarshalBinary(data[:2])).Should(gomega.Equal(ErrInvalidHash), "too short")
+	g.Expect(h.UnmarshalBinary(data[:9])).Should(gomega.Equal(ErrInvalidHash), "truncated varint")
+	g.Expect(h.UnmarshalBinary(data[:26])).Should(gomega.Equal(ErrInvalidHash), "no hash")
+	g.Expect(h.UnmarshalBinary(modified(0, 9))).Should(gomega.Equal(ErrInvalidHash), "layout version")
+	g.Expect(h.UnmarshalBinary(modified(1, 0))).Should(gomega.Equal(ErrInvalidHash), "format")
+	g.Expect(h.UnmarshalBinary(modified(1, 9))).Should(gomega.Equal(ErrInvalidHash), "unknown format code")
+	g.Expect(h.UnmarshalBinary(modified(1, 0xff))).Should(gomega.Equal(ErrInvalidHash), "unknown format code")
+	g.Expect(h.UnmarshalBinary(modified(2, 1))).Should(gomega.Equal(ErrInvalidHash), "variant")
+	g.Expect(h.UnmarshalBinary(modified(3, 16))).Should(gomega.Equal(ErrInvalidArgon2Version), "argon2 version")
+	g.Expect(h.UnmarshalBinary(modified(4, 0))).Should(gomega.Equal(ErrInvalidComplexity), "time")
+	g.Expect(h.UnmarshalBinary(modified(8, 0))).Should(gomega.Equal(ErrInvalidComplexity), "threads")
+	g.Expect(h.UnmarshalBinary(modified(9, 0))).Should(gomega.Equal(ErrInvalidHash), "salt length")
+	g.Expect(CompareBinary(data[:2], "my-password")).Should(gomega.Equal(ErrInvalidHash))
+
+	// the scheme prefix must be read back as the same format
+	prefixed, _ := Hash("{Argon2}" + referencePHCHash).MarshalBinary()
+	g.Expect(prefixed[:12]).Should(gomega.Equal([]byte{2, 4, 2, 8, '{', 'A', 'r', 'g', 'o', 'n', '2', '}'}))
+	g.Expect(h.UnmarshalBinary(prefixed)).Should(gomega.Succeed())
+	g.Expect(h.UnmarshalBinary(prefixed[:6])).Should(gomega.Equal(ErrInvalidHash), "truncated prefix")
+	for i, b := range []byte{'x', '$'} {
+		d := append([]byte(nil), prefixed...)
+		d[4+i] = b
+		g.Expect(h.UnmarshalBinary(d)).Should(gomega.Equal(ErrInvalidHash), "prefix")
+	}
+	d := append([]byte(nil), prefixed...)
+	d[1] = 5
+	g.Expect(h.UnmarshalBinary(d)).Should(gomega.Equal(ErrInvalidHash), "prefix of another format")
+}
@@ -0,0 +1,51 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import "github.com/synacor/argon2id/securebuf"
+
+// CompareBuffer is CompareBytes for a password held in a securebuf.Buffer. The Buffer cannot be closed while the
+// comparison runs. If it is already closed, securebuf.ErrClosed is returned.
+func CompareBuffer(hashedPassword string, password *securebuf.Buffer) error {
+	return password.Use(func(pw []byte) error {
+		return CompareBytes(hashedPassword, pw)
+	})
+}
+
+// HashBuffer is HashBytes for a password held in a securebuf.Buffer. The Buffer cannot be closed while the hash is
+// computed. If it is already closed, securebuf.ErrClosed is returned.
+func (hr Hasher) HashBuffer(password *securebuf.Buffer) (hashedPassword string, err error) {
+	err = password.Use(func(pw []byte) error {
+		hashedPassword, err = hr.HashBytes(pw)
+		return err
+	})
+
+	return hashedPassword, err
+}
+
+// KeyBuffer is Key for a password held in a securebuf.Buffer, such as a pepper or key escrow secret. The Buffer
+// cannot be closed while the key is derived. If it is already closed, securebuf.ErrClosed is returned.
+func KeyBuffer(password *securebuf.Buffer, salt []byte, p Params) (key []byte, err error) {
+	err = password.Use(func(pw []byte) error {
+		key = Key(pw, salt, p)
+		return nil
+	})
+
+	return key, err
+}
@@ -0,0 +1,50 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id/securebuf"
+)
+
+func TestBufferAPIs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	b, err := securebuf.FromBytes([]byte("my-password"))
+	g.Expect(err).Should(gomega.Succeed())
+
+	hashedPassword, err := Hasher{Params: Params{Memory: 1024}}.HashBuffer(b)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(CompareBuffer(hashedPassword, b)).Should(gomega.Succeed())
+	g.Expect(Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+
+	p := Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16}
+	key, err := KeyBuffer(b, []byte("somesalt"), p)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(key).Should(gomega.Equal(Key([]byte("my-password"), []byte("somesalt"), p)))
+
+	g.Expect(b.Close()).Should(gomega.Succeed())
+	g.Expect(CompareBuffer(hashedPassword, b)).Should(gomega.Equal(securebuf.ErrClosed))
+	_, err = Hasher{}.HashBuffer(b)
+	g.Expect(err).Should(gomega.Equal(securebuf.ErrClosed))
+	_, err = KeyBuffer(b, []byte("somesalt"), p)
+	g.Expect(err).Should(gomega.Equal(securebuf.ErrClosed))
+}
@@ -0,0 +1,375 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultCgroupRoot is where the cgroup filesystem is mounted. Inside a container it shows the container's own limits.
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// procSelfCgroup lists the cgroups of this process. It is a variable so that tests can use fixtures.
+var procSelfCgroup = "/proc/self/cgroup"
+
+// cgroup v1 reports "no limit" as a huge page-aligned number rather than a keyword
+const cgroupV1Unlimited = 1 << 62
+
+// the share of the memory limit that a single hash may use, leaving room for concurrent hashes and the application
+const containerMemoryShare = 8
+
+// the smallest memory the environment-aware defaults will choose, in KiB. It is the lowest of the OWASP minimums.
+const containerMinMemory = 7 * 1024
+
+// EnvironmentDefaults are parameters derived from the limits of the container or machine, with the reasoning
+type EnvironmentDefaults struct {
+	// Params are the derived parameters
+	Params Params
+
+	// MemoryLimit is the cgroup memory limit in bytes, or "0" if there is none
+	MemoryLimit uint64
+
+	// CPULimit is the cgroup CPU quota in CPUs, or "0" if there is none
+	CPULimit float64
+
+	// GOMAXPROCS is the number of CPUs Go may use at once
+	GOMAXPROCS int
+
+	// Reasons explain, one line each, how the limits led to the parameters
+	Reasons []string
+}
+
+// DetectDefaults will derive defaults from the cgroup v1 or v2 limits under DefaultCgroupRoot and GOMAXPROCS
+func DetectDefaults() (EnvironmentDefaults, error) {
+	return DetectDefaultsFrom(DefaultCgroupRoot, runtime.GOMAXPROCS(0))
+}
+
+// DetectDefaultsFrom will derive defaults from the cgroup filesystem at root and the given GOMAXPROCS. The limits are
+// those of the process's own cgroup, as listed in /proc/self/cgroup, and of its parents up to root; the lowest one
+// applies. The threads are capped by the CPU quota and GOMAXPROCS. Memory is capped at an eighth of the memory limit,
+// and the time is raised so that the total work stays close to that of the sane defaults. Without limits, the sane
+// defaults are returned.
+func DetectDefaultsFrom(root string, gomaxprocs int) (EnvironmentDefaults, error) {
+	d := EnvironmentDefaults{Params: DefaultParams(), GOMAXPROCS: gomaxprocs}
+	paths := cgroupPaths()
+
+	var err error
+	if fileExists(filepath.Join(root, "cgroup.controllers")) {
+		d.Reasons = append(d.Reasons, "found cgroup v2 at "+root)
+		d.reportCgroup(paths[""])
+		dirs := cgroupDirs(root, paths[""])
+		if d.MemoryLimit, err = readCgroupV2Memory(dirs); err != nil {
+			return d, err
+		}
+		if d.CPULimit, err = readCgroupV2CPU(dirs); err != nil {
+			return d, err
+		}
+	} else if fileExists(filepath.Join(root, "memory")) || fileExists(filepath.Join(root, "cpu")) {
+		d.Reasons = append(d.Reasons, "found cgroup v1 at "+root)
+		d.reportCgroup(paths["memory"])
+		if d.MemoryLimit, err = readCgroupV1Memory(cgroupDirs(filepath.Join(root, "memory"), paths["memory"])); err != nil {
+			return d, err
+		}
+		if d.CPULimit, err = readCgroupV1CPU(cgroupDirs(filepath.Join(root, "cpu"), paths["cpu"])); err != nil {
+			return d, err
+		}
+	} else {
+		d.Reasons = append(d.Reasons, "no cgroup found at "+root)
+	}
+
+	d.deriveThreads()
+	d.deriveMemory()
+	return d, nil
+}
+
+func (d *EnvironmentDefaults) reportCgroup(path string) {
+	if path = cleanCgroupPath(path); path != "/" {
+		d.Reasons = append(d.Reasons, "process is in cgroup "+path)
+	}
+}
+
+// cgroupPaths will return the process's cgroup path for each cgroup v1 controller, and for cgroup v2 under "". A
+// missing entry means the root.
+func cgroupPaths() map[string]string {
+	paths := make(map[string]string)
+	data, err := ioutil.ReadFile(procSelfCgroup)
+	if err != nil {
+		return paths
+	}
+
+	// "hierarchy-ID:controller-list:cgroup-path", where the controller list is empty for cgroup v2
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+
+	return paths
+}
+
+// cleanCgroupPath will return path as an absolute path. A path above the root, as seen from a cgroup namespace that
+// does not include the process, becomes the root.
+func cleanCgroupPath(path string) string {
+	return filepath.Clean("/" + path)
+}
+
+// cgroupDirs will return the directory of the cgroup at path under root, followed by those of its parents up to root
+func cgroupDirs(root, path string) []string {
+	path = cleanCgroupPath(path)
+
+	var dirs []string
+	for {
+		dirs = append(dirs, filepath.Join(root, path))
+		if path == "/" {
+			return dirs
+		}
+		path = filepath.Dir(path)
+	}
+}
+
+// lowerLimit will return the lower of two limits, where "0" means there is none
+func lowerLimit(a, b uint64) uint64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+
+	return a
+}
+
+// lowerQuota will return the lower of two CPU quotas, where "0" means there is none
+func lowerQuota(a, b float64) float64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+
+	return a
+}
+
+func (d *EnvironmentDefaults) deriveThreads() {
+	threads := int(d.Params.Threads)
+	if d.CPULimit > 0 {
+		cpus := int(d.CPULimit + 0.999)
+		d.Reasons = append(d.Reasons, fmt.Sprintf("CPU quota is %.2f CPUs", d.CPULimit))
+		if cpus < threads {
+			threads = cpus
+		}
+	} else {
+		d.Reasons = append(d.Reasons, "no CPU quota")
+	}
+
+	if d.GOMAXPROCS > 0 && d.GOMAXPROCS < threads {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("GOMAXPROCS is %d", d.GOMAXPROCS))
+		threads = d.GOMAXPROCS
+	}
+
+	if threads < 1 {
+		threads = 1
+	}
+
+	d.Params.Threads = uint8(threads)
+	d.Reasons = append(d.Reasons, fmt.Sprintf("threads=%d", threads))
+}
+
+func (d *EnvironmentDefaults) deriveMemory() {
+	if d.MemoryLimit == 0 {
+		d.Reasons = append(d.Reasons, "no memory limit")
+		d.Reasons = append(d.Reasons, fmt.Sprintf("memory=%d KiB, time=%d", d.Params.Memory, d.Params.Time))
+		return
+	}
+
+	d.Reasons = append(d.Reasons, fmt.Sprintf("memory limit is %d KiB", d.MemoryLimit/1024))
+
+	memory := d.MemoryLimit / 1024 / containerMemoryShare
+	memory -= memory % 1024
+	if memory < containerMinMemory {
+		memory = containerMinMemory
+	}
+
+	if memory < uint64(d.Params.Memory) {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("one hash may use 1/%d of the limit", containerMemoryShare))
+
+		// raise the passes so that memory times time stays close to the defaults, as the OWASP minimums do
+		work := uint64(d.Params.Memory) * uint64(d.Params.Time)
+		time := (work + memory - 1) / memory
+		d.Params.Memory = uint32(memory)
+		d.Params.Time = uint32(time)
+	}
+
+	if min := uint32(8) * uint32(d.Params.Threads); d.Params.Memory < min {
+		d.Params.Memory = min
+	}
+
+	d.Reasons = append(d.Reasons, fmt.Sprintf("memory=%d KiB, time=%d", d.Params.Memory, d.Params.Time))
+}
+
+func readCgroupV2Memory(dirs []string) (uint64, error) {
+	var limit uint64
+	for _, dir := range dirs {
+		value, ok, err := readCgroupFile(filepath.Join(dir, "memory.max"))
+		if err != nil {
+			return 0, err
+		}
+		if !ok || value == "max" {
+			continue
+		}
+
+		l, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("synacor/argon2id: invalid memory.max %q", value)
+		}
+		limit = lowerLimit(limit, l)
+	}
+
+	return limit, nil
+}
+
+func readCgroupV2CPU(dirs []string) (float64, error) {
+	var limit float64
+	for _, dir := range dirs {
+		value, ok, err := readCgroupFile(filepath.Join(dir, "cpu.max"))
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		// "$MAX $PERIOD", where $MAX may be "max"
+		fields := strings.Fields(value)
+		if len(fields) == 0 || len(fields) > 2 {
+			return 0, fmt.Errorf("synacor/argon2id: invalid cpu.max %q", value)
+		}
+		if fields[0] == "max" {
+			continue
+		}
+
+		period := "100000"
+		if len(fields) == 2 {
+			period = fields[1]
+		}
+
+		quota, err := cpuQuota(fields[0], period, "cpu.max")
+		if err != nil {
+			return 0, err
+		}
+		limit = lowerQuota(limit, quota)
+	}
+
+	return limit, nil
+}
+
+func readCgroupV1Memory(dirs []string) (uint64, error) {
+	var limit uint64
+	for _, dir := range dirs {
+		value, ok, err := readCgroupFile(filepath.Join(dir, "memory.limit_in_bytes"))
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		l, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("synacor/argon2id: invalid memory.limit_in_bytes %q", value)
+		}
+		if l < cgroupV1Unlimited {
+			limit = lowerLimit(limit, l)
+		}
+	}
+
+	return limit, nil
+}
+
+func readCgroupV1CPU(dirs []string) (float64, error) {
+	var limit float64
+	for _, dir := range dirs {
+		quota, ok, err := readCgroupFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if err != nil {
+			return 0, err
+		}
+		if !ok || quota == "-1" {
+			continue
+		}
+
+		period, ok, err := readCgroupFile(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			period = "100000"
+		}
+
+		q, err := cpuQuota(quota, period, "cpu.cfs_quota_us")
+		if err != nil {
+			return 0, err
+		}
+		limit = lowerQuota(limit, q)
+	}
+
+	return limit, nil
+}
+
+func cpuQuota(quota, period, name string) (float64, error) {
+	q, err := strconv.ParseUint(quota, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("synacor/argon2id: invalid %s %q", name, quota)
+	}
+
+	p, err := strconv.ParseUint(period, 10, 64)
+	if err != nil || p == 0 {
+		return 0, fmt.Errorf("synacor/argon2id: invalid CPU period %q", period)
+	}
+
+	return float64(q) / float64(p), nil
+}
+
+// readCgroupFile will return the trimmed contents of a cgroup file, or false if it does not exist
+func readCgroupFile(name string) (string, bool, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,142 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// useProcSelfCgroup will read the process's cgroups from a fixture until the returned function is called
+func useProcSelfCgroup(name string) (reset func()) {
+	old := procSelfCgroup
+	procSelfCgroup = name
+	return func() { procSelfCgroup = old }
+}
+
+func TestDetectDefaultsFromCgroupV2(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer useProcSelfCgroup("testdata/cgroup/self-root")()
+
+	// a 256 MiB, 0.5 CPU container
+	d, err := DetectDefaultsFrom("testdata/cgroup/v2-limited", 8)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.Equal(uint64(256 * 1024 * 1024)))
+	g.Expect(d.CPULimit).Should(gomega.Equal(0.5))
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 2, Memory: 32 * 1024, Threads: 1, KeyLen: 32}))
+	g.Expect(d.Reasons).Should(gomega.Equal([]string{
+		"found cgroup v2 at testdata/cgroup/v2-limited",
+		"CPU quota is 0.50 CPUs",
+		"threads=1",
+		"memory limit is 262144 KiB",
+		"one hash may use 1/8 of the limit",
+		"memory=32768 KiB, time=2",
+	}))
+
+	d, err = DetectDefaultsFrom("testdata/cgroup/v2-unlimited", 2)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.BeZero())
+	g.Expect(d.CPULimit).Should(gomega.BeZero())
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 1, Memory: 64 * 1024, Threads: 2, KeyLen: 32}))
+	g.Expect(d.Reasons).Should(gomega.ContainElement("GOMAXPROCS is 2"))
+
+	_, err = DetectDefaultsFrom("testdata/cgroup/v2-invalid", 2)
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: invalid memory.max "lots"`))
+}
+
+func TestDetectDefaultsFromCgroupV1(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer useProcSelfCgroup("testdata/cgroup/self-root")()
+
+	// a 2 GiB, 2 CPU container leaves the memory default alone
+	d, err := DetectDefaultsFrom("testdata/cgroup/v1-limited", 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.Equal(uint64(2 * 1024 * 1024 * 1024)))
+	g.Expect(d.CPULimit).Should(gomega.Equal(2.0))
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 1, Memory: 64 * 1024, Threads: 2, KeyLen: 32}))
+
+	d, err = DetectDefaultsFrom("testdata/cgroup/v1-unlimited", 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.BeZero())
+	g.Expect(d.CPULimit).Should(gomega.BeZero())
+	g.Expect(d.Params).Should(gomega.Equal(DefaultParams()))
+	g.Expect(d.Reasons).Should(gomega.Equal([]string{
+		"found cgroup v1 at testdata/cgroup/v1-unlimited",
+		"no CPU quota",
+		"threads=4",
+		"no memory limit",
+		"memory=65536 KiB, time=1",
+	}))
+}
+
+func TestDetectDefaultsFromNestedCgroup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// a systemd service with no limit of its own in a slice limited to 256 MiB; the root has no limit files at all
+	reset := useProcSelfCgroup("testdata/cgroup/self-v2-nested")
+	d, err := DetectDefaultsFrom("testdata/cgroup/v2-nested", 8)
+	reset()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.Equal(uint64(256 * 1024 * 1024)))
+	g.Expect(d.CPULimit).Should(gomega.Equal(1.5))
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 2, Memory: 32 * 1024, Threads: 2, KeyLen: 32}))
+	g.Expect(d.Reasons[:2]).Should(gomega.Equal([]string{
+		"found cgroup v2 at testdata/cgroup/v2-nested",
+		"process is in cgroup /system.slice/app.service",
+	}))
+
+	// the same tree seen from the root cgroup has no limits
+	reset = useProcSelfCgroup("testdata/cgroup/self-root")
+	d, err = DetectDefaultsFrom("testdata/cgroup/v2-nested", 8)
+	reset()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.BeZero())
+	g.Expect(d.CPULimit).Should(gomega.BeZero())
+
+	// a container sharing the host's cgroup namespace, with cgroup v1
+	reset = useProcSelfCgroup("testdata/cgroup/self-v1-nested")
+	d, err = DetectDefaultsFrom("testdata/cgroup/v1-nested", 8)
+	reset()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.MemoryLimit).Should(gomega.Equal(uint64(128 * 1024 * 1024)))
+	g.Expect(d.CPULimit).Should(gomega.Equal(1.0))
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 4, Memory: 16 * 1024, Threads: 1, KeyLen: 32}))
+
+	// paths cannot leave the mounted hierarchy, and without /proc/self/cgroup the process is in the root
+	g.Expect(cgroupDirs("/sys/fs/cgroup", "/../../x")).Should(gomega.Equal([]string{"/sys/fs/cgroup/x", "/sys/fs/cgroup"}))
+	g.Expect(cgroupDirs("/sys/fs/cgroup", "")).Should(gomega.Equal([]string{"/sys/fs/cgroup"}))
+	reset = useProcSelfCgroup("testdata/cgroup/missing")
+	g.Expect(cgroupPaths()).Should(gomega.BeEmpty())
+	reset()
+}
+
+func TestDetectDefaultsWithoutCgroup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	d, err := DetectDefaultsFrom("testdata/cgroup/missing", 1)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.Params).Should(gomega.Equal(Params{Time: 1, Memory: 64 * 1024, Threads: 1, KeyLen: 32}))
+	g.Expect(d.Reasons[0]).Should(gomega.Equal("no cgroup found at testdata/cgroup/missing"))
+
+	// whatever this machine has, the result can be hashed with
+	d, err = DetectDefaults()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(d.Params.Threads).ShouldNot(gomega.BeZero())
+}
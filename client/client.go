@@ -0,0 +1,307 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package client talks to the argon2id daemon. Its functions have the same signatures and return the same errors as
+// the argon2id package, so switching a caller over to the daemon only means changing the import path:
+//
+//	import argon2id "github.com/synacor/argon2id/client"
+//
+// The package level functions use the daemon at the address in the ARGON2ID_SERVER environment variable, or
+// unix:/run/argon2id/argon2id.sock if it is not set.
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/internal/protocol"
+)
+
+// These are the same values as the argon2id package's errors, so they can be compared directly
+var (
+	ErrInvalidHash               = argon2id.ErrInvalidHash
+	ErrInvalidComplexity         = argon2id.ErrInvalidComplexity
+	ErrInvalidArgon2Version      = argon2id.ErrInvalidArgon2Version
+	ErrMismatchedHashAndPassword = argon2id.ErrMismatchedHashAndPassword
+)
+
+// AddressEnv is the environment variable that holds the daemon's address for the package level functions
+const AddressEnv = "ARGON2ID_SERVER"
+
+// maxIdle is the number of connections a Client keeps open between calls
+const maxIdle = 8
+
+// Client is a connection pool to a single daemon. It is safe for concurrent use.
+type Client struct {
+	// Timeout bounds how long a single call may take, including waiting for the daemon's concurrency limits. If "0",
+	// there is no timeout.
+	Timeout time.Duration
+
+	address string
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// New will return a Client for the daemon at address, which is of the form "unix:<path>" or "tcp:<host>:<port>".
+// No connection is made until the first call.
+func New(address string) *Client {
+	return &Client{address: address}
+}
+
+var defaultClient struct {
+	once   sync.Once
+	client *Client
+}
+
+// Default will return the Client used by the package level functions
+func Default() *Client {
+	defaultClient.once.Do(func() {
+		address := os.Getenv(AddressEnv)
+		if address == "" {
+			address = protocol.DefaultAddress
+		}
+		defaultClient.client = New(address)
+	})
+
+	return defaultClient.client
+}
+
+// IsHashedPassword will return true if hashedPassword is a proper password hashed by this library. It does not
+// contact the daemon.
+func IsHashedPassword(hashedPassword string) bool {
+	return argon2id.IsHashedPassword(hashedPassword)
+}
+
+// DefaultHashPassword is a convenience function that calls HashPassword() with default values
+func DefaultHashPassword(password string) (string, error) {
+	return Default().HashPassword(password, 0, 0, 0, 0)
+}
+
+// HashPassword will hash the password on the daemon. See argon2id.HashPassword.
+func HashPassword(password string, time, memory uint32, threads uint8, keyLen uint32) (string, error) {
+	return Default().HashPassword(password, time, memory, threads, keyLen)
+}
+
+// Compare will compare the hashedPassword with the supplied password on the daemon. See argon2id.Compare.
+func Compare(hashedPassword, password string) error {
+	return Default().Compare(hashedPassword, password)
+}
+
+// NeedsRehash will ask the daemon whether hashedPassword was created with other parameters. See argon2id.NeedsRehash.
+func NeedsRehash(hashedPassword string, time, memory uint32, threads uint8, keyLen uint32) (bool, error) {
+	return Default().NeedsRehash(hashedPassword, time, memory, threads, keyLen)
+}
+
+// DefaultHashPassword is a convenience function that calls HashPassword() with default values
+func (c *Client) DefaultHashPassword(password string) (string, error) {
+	return c.HashPassword(password, 0, 0, 0, 0)
+}
+
+// HashPassword will hash the password on the daemon. See argon2id.HashPassword.
+func (c *Client) HashPassword(password string, time, memory uint32, threads uint8, keyLen uint32) (string, error) {
+	pw := []byte(password)
+	defer wipe(pw)
+	resp, err := c.do(protocol.Request{
+		Op:       protocol.OpHash,
+		Password: pw,
+		Time:     time,
+		Memory:   memory,
+		Threads:  threads,
+		KeyLen:   keyLen,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.HashedPassword, resp.Err()
+}
+
+// Compare will compare the hashedPassword with the supplied password on the daemon. See argon2id.Compare.
+func (c *Client) Compare(hashedPassword, password string) error {
+	pw := []byte(password)
+	defer wipe(pw)
+	resp, err := c.do(protocol.Request{
+		Op:             protocol.OpCompare,
+		HashedPassword: hashedPassword,
+		Password:       pw,
+	})
+	if err != nil {
+		return err
+	}
+
+	return resp.Err()
+}
+
+// NeedsRehash will ask the daemon whether hashedPassword was created with other parameters. See argon2id.NeedsRehash.
+func (c *Client) NeedsRehash(hashedPassword string, time, memory uint32, threads uint8, keyLen uint32) (bool, error) {
+	resp, err := c.do(protocol.Request{
+		Op:             protocol.OpNeedsRehash,
+		HashedPassword: hashedPassword,
+		Time:           time,
+		Memory:         memory,
+		Threads:        threads,
+		KeyLen:         keyLen,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return resp.NeedsRehash, resp.Err()
+}
+
+// Close will close the idle connections. The Client can still be used afterwards.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.mu.Unlock()
+
+	var err error
+	for _, cn := range idle {
+		if cerr := cn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+func (c *Client) do(req protocol.Request) (*protocol.Response, error) {
+	cn, reused, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.roundTrip(cn, req)
+	if err != nil && reused && !isTimeout(err) {
+		// the daemon may have closed an idle connection (e.g. it restarted), so try once more on a new one. A timeout
+		// is not retried: the daemon may still be working on the request, and the caller's Timeout has been used up.
+		cn.Close()
+		if cn, err = c.dial(); err != nil {
+			return nil, err
+		}
+		resp, err = c.roundTrip(cn, req)
+	}
+
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+
+	c.put(cn)
+	return resp, nil
+}
+
+// wipe will overwrite b with zeros once the request holding it has been sent
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// isTimeout will return true if err is a deadline set by Timeout expiring
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+func (c *Client) roundTrip(cn *conn, req protocol.Request) (*protocol.Response, error) {
+	if c.Timeout > 0 {
+		if err := cn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.NewEncoder(cn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	line, err := cn.reader.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, err
+	}
+
+	if c.Timeout > 0 {
+		if err := cn.SetDeadline(time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) get() (cn *conn, reused bool, err error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn = c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, true, nil
+	}
+	c.mu.Unlock()
+
+	cn, err = c.dial()
+	return cn, false, err
+}
+
+func (c *Client) dial() (*conn, error) {
+	network, addr, err := protocol.SplitAddress(c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	var netConn net.Conn
+	if c.Timeout > 0 {
+		netConn, err = net.DialTimeout(network, addr, c.Timeout)
+	} else {
+		netConn, err = net.Dial(network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: netConn, reader: bufio.NewReaderSize(netConn, protocol.MaxMessageSize)}, nil
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.idle) >= maxIdle {
+		cn.Close()
+		return
+	}
+
+	c.idle = append(c.idle, cn)
+}
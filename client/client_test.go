@@ -0,0 +1,141 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package client
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/server"
+)
+
+func TestClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+
+	address := "unix:" + filepath.Join(dir, "argon2id.sock")
+	l, err := server.Listen(address)
+	g.Expect(err).Should(gomega.Succeed())
+
+	s := &server.Server{MaxConcurrent: 2}
+	go s.Serve(l)
+	defer s.Close()
+
+	c := New(address)
+	defer c.Close()
+
+	h, err := c.HashPassword("test", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(argon2id.Compare(h, "test")).Should(gomega.Succeed())
+	g.Expect(IsHashedPassword(h)).Should(gomega.BeTrue())
+
+	g.Expect(c.Compare(h, "test")).Should(gomega.Succeed())
+	g.Expect(c.Compare(h, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(c.Compare("bad-hash", "test")).Should(gomega.Equal(argon2id.ErrInvalidHash))
+
+	needsRehash, err := c.NeedsRehash(h, 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	needsRehash, err = c.NeedsRehash(h, 2, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeTrue())
+
+	// passwords that are not valid UTF-8 arrive unchanged
+	latin1, err := c.HashPassword("p\xe9ss", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(argon2id.Compare(latin1, "p\xe9ss")).Should(gomega.Succeed())
+	g.Expect(c.Compare(latin1, "p\xe8ss")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	local, err := argon2id.HashPassword("p\xe9ss", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(c.Compare(local, "p\xe9ss")).Should(gomega.Succeed())
+	g.Expect(c.Compare(local, "p\ufffdss")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	// idle connections that the daemon has dropped are replaced
+	s.Close()
+	s2 := &server.Server{}
+	os.Remove(filepath.Join(dir, "argon2id.sock"))
+	l, err = server.Listen(address)
+	g.Expect(err).Should(gomega.Succeed())
+	go s2.Serve(l)
+	defer s2.Close()
+
+	g.Expect(c.Compare(h, "test")).Should(gomega.Succeed())
+}
+
+func TestClientTimeoutNotRetried(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).Should(gomega.Succeed())
+	defer l.Close()
+
+	// the daemon answers the first request on each connection and never the second
+	var conns int32
+	go func() {
+		for {
+			cn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&conns, 1)
+			go func() {
+				defer cn.Close()
+				r := bufio.NewReader(cn)
+				r.ReadSlice('\n')
+				cn.Write([]byte("{}\n"))
+				r.ReadSlice('\n')
+				time.Sleep(time.Second)
+			}()
+		}
+	}()
+
+	c := New("tcp:" + l.Addr().String())
+	c.Timeout = 100 * time.Millisecond
+	defer c.Close()
+
+	g.Expect(c.Compare("hash", "test")).Should(gomega.Succeed())
+
+	err = c.Compare("hash", "test")
+	g.Expect(err).Should(gomega.BeAssignableToTypeOf(&net.OpError{}))
+	g.Expect(err.(net.Error).Timeout()).Should(gomega.BeTrue())
+	g.Expect(atomic.LoadInt32(&conns)).Should(gomega.Equal(int32(1)))
+}
+
+func TestClientWithoutDaemon(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := New("unix:/nonexistent/argon2id.sock")
+	g.Expect(c.Compare("bad-hash", "test")).ShouldNot(gomega.Succeed())
+
+	c = New("bogus")
+	_, err := c.DefaultHashPassword("test")
+	g.Expect(err).ShouldNot(gomega.Succeed())
+}
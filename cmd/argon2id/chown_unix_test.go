@@ -0,0 +1,58 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id/passwdfile"
+)
+
+func TestUpdatePasswdFileOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner of a file needs root")
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "passwd")
+	g.Expect(ioutil.WriteFile(name, []byte("bob:{PLAIN}secret:1001:1001\n"), 0640)).Should(gomega.Succeed())
+	g.Expect(os.Chown(name, 1001, 1002)).Should(gomega.Succeed())
+
+	g.Expect(updatePasswdFile(name, passwdfile.Entry{User: "alice", Password: "{PLAIN}new"})).Should(gomega.Succeed())
+
+	info, err := os.Stat(name)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(info.Mode().Perm()).Should(gomega.Equal(os.FileMode(0640)))
+	stat := info.Sys().(*syscall.Stat_t)
+	g.Expect(stat.Uid).Should(gomega.BeEquivalentTo(1001))
+	g.Expect(stat.Gid).Should(gomega.BeEquivalentTo(1002))
+}
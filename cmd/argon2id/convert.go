@@ -0,0 +1,97 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// stdin is where subcommands that process a stream of lines read from
+var stdin io.Reader = os.Stdin
+
+// runConvert rewrites hashed passwords in another format. Hashes are taken from the arguments or, if there are none,
+// one per line from stdin. A line that cannot be converted is written unchanged so that the output stays aligned
+// with the input, and the error is reported on stderr.
+func runConvert(stdout, stderr io.Writer, args []string) int {
+	flagset := flag.NewFlagSet(os.Args[0]+" convert", flag.ExitOnError)
+	flagset.SetOutput(stderr)
+
+	to := flagset.String("to", argon2id.FormatPHC.String(), "format to convert to: "+formatNames())
+	flagset.Parse(args)
+
+	format, err := argon2id.ParseFormat(*to)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	exitStatus := exitStatusNormal
+	convert := func(name, hashedPassword string) {
+		converted, err := argon2id.Convert(hashedPassword, format)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			exitStatus = exitStatusError
+			converted = hashedPassword
+		}
+
+		fmt.Fprintln(stdout, converted)
+	}
+
+	if flagset.NArg() > 0 {
+		for i, hashedPassword := range flagset.Args() {
+			convert(fmt.Sprintf("argument %d", i+1), hashedPassword)
+		}
+
+		return exitStatus
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	for line := 1; scanner.Scan(); line++ {
+		hashedPassword := strings.TrimSpace(scanner.Text())
+		if hashedPassword == "" {
+			fmt.Fprintln(stdout)
+			continue
+		}
+
+		convert(fmt.Sprintf("line %d", line), hashedPassword)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "could not read input: %v\n", err)
+		return exitStatusError
+	}
+
+	return exitStatus
+}
+
+func formatNames() string {
+	var names []string
+	for _, f := range argon2id.Formats() {
+		names = append(names, f.String())
+	}
+
+	return strings.Join(names, ", ")
+}
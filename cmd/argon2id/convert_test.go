@@ -0,0 +1,71 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+const testHash = "$argon2id19$1,65536,4$fjSIS8wLOEZRF/9ceB3Ct.$YCdi8.UQsEGFBsAwVGH/U5lwlvHWLbUl7MzSXwFJ7Oy"
+const testPHCHash = "$argon2id$v=19$m=65536,t=1,p=4$hlUKU+yNQGbTHB/egD5EvA$aEfk+AWSuGIHDuCyXIJBW7nynxJYNdWn9O1UZyHL9Q0"
+
+func mockStdin(input string) (reset func()) {
+	oldStdin := stdin
+	stdin = strings.NewReader(input)
+	return func() { stdin = oldStdin }
+}
+
+func TestRunConvertArguments(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "convert "+testHash)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.Equal(testPHCHash + "\n"))
+	g.Expect(stderr).Should(gomega.Equal(""))
+
+	exitStatus, stdout, stderr = runTest(false, "convert -to synacor "+testPHCHash)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.Equal(testHash + "\n"))
+	g.Expect(stderr).Should(gomega.Equal(""))
+}
+
+func TestRunConvertStream(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockStdin(testHash + "\n\nbad-hash\n" + testHash + "\n")
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(false, "convert -to phc")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(testPHCHash + "\n\nbad-hash\n" + testPHCHash + "\n"))
+	g.Expect(stderr).Should(gomega.Equal("line 3: " + argon2id.ErrInvalidHash.Error() + "\n"))
+}
+
+func TestRunConvertWithBadFormat(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "convert -to bogus "+testHash)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal(`synacor/argon2id: unknown format "bogus"` + "\n"))
+}
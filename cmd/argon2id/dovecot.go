@@ -0,0 +1,155 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/passwdfile"
+)
+
+// runDovecot prompts for a password and prints a Dovecot passwd-file line for the user with an {ARGON2ID} hash. With
+// -file, the user's line in that passwd-file is replaced, or added, instead.
+func runDovecot(stdout, stderr io.Writer, args []string) int {
+	flagset := flag.NewFlagSet(os.Args[0]+" dovecot", flag.ExitOnError)
+	flagset.SetOutput(stderr)
+
+	user := flagset.String("user", "", "the user name for the passwd-file line")
+	uid := flagset.String("uid", "", "the uid of the user")
+	gid := flagset.String("gid", "", "the gid of the user")
+	home := flagset.String("home", "", "the home directory of the user")
+	extra := flagset.String("extra", "", "space separated extra fields, such as userdb_mail=maildir:~/Maildir")
+	file := flagset.String("file", "", "a passwd-file to update in place instead of printing the line")
+	quiet := flagset.Bool("q", false, "do not print the "+prompt+" text")
+	timeComplexity := flagset.Int("time", 0, "time complexity when generating hash")
+	memoryComplexity := flagset.Int("memory", 0, "memory complexity when generating hash")
+	numThreads := flagset.Int("threads", 0, "number of threads to use when generating hash")
+	preset := flagset.String("preset", "", "named parameters to start from, overridden by the other flags: "+presetNames())
+	flagset.Parse(args)
+
+	if *user == "" {
+		fmt.Fprintf(stderr, "a user name is required\n")
+		return exitStatusError
+	}
+
+	params, err := hashParams(*preset, *timeComplexity, *memoryComplexity, *numThreads, 0)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	pwBytes, ok := promptPassword(stdout, stderr, *quiet)
+	if !ok {
+		return exitStatusError
+	}
+
+	defer wipe(pwBytes)
+
+	hashedPassword, err := argon2id.Hasher{Params: params, Format: argon2id.FormatDovecot, Progress: progressBar(stderr)}.HashBytes(pwBytes)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not hash password: %v\n", err)
+		return exitStatusError
+	}
+
+	entry, err := passwdfile.NewEntry(*user, hashedPassword)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitStatusError
+	}
+	entry.UID, entry.GID, entry.Home, entry.Extra = *uid, *gid, *home, *extra
+
+	if *file == "" {
+		fmt.Fprintln(stdout, entry)
+		return exitStatusNormal
+	}
+
+	if err := updatePasswdFile(*file, entry); err != nil {
+		fmt.Fprintf(stderr, "could not update %s: %v\n", *file, err)
+		return exitStatusError
+	}
+
+	return exitStatusNormal
+}
+
+// updatePasswdFile will replace the passwd-file with an updated copy, so that Dovecot never reads a partial file. The
+// copy keeps the mode, owner and group of the file.
+func updatePasswdFile(name string, entry passwdfile.Entry) error {
+	// a symlink is left in place and the file it points to is replaced
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if info != nil {
+		mode = info.Mode().Perm()
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := passwdfile.Update(bytes.NewReader(data), buf, entry); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if info != nil {
+		if err := copyOwner(tmp, info); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
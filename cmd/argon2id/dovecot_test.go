@@ -0,0 +1,130 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/passwdfile"
+)
+
+func TestRunDovecot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(true, "dovecot -user alice -uid 1000 -gid 1000 -home /home/alice -memory 1024")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`^alice:\{ARGON2ID\}\$argon2id\$v=19\$m=1024,t=1,p=4\$[^:]+:1000:1000::/home/alice\n$`))
+
+	entry, err := passwdfile.ParseLine(strings.TrimSpace(stdout))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(argon2id.Compare(entry.Password, "my-password")).Should(gomega.Succeed())
+}
+
+func TestRunDovecotWithFile(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "passwd")
+	g.Expect(ioutil.WriteFile(name, []byte("alice:{PLAIN}old:1000:1000\nbob:{PLAIN}secret:1001:1001\n"), 0640)).Should(gomega.Succeed())
+
+	exitStatus, stdout, stderr := runTest(false, "dovecot -q -user alice -uid 1000 -gid 1000 -memory 1024 -file "+name)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal(""))
+
+	data, err := ioutil.ReadFile(name)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(data)).Should(gomega.MatchRegexp(`^alice:\{ARGON2ID\}[^:]+:1000:1000\nbob:\{PLAIN\}secret:1001:1001\n$`))
+
+	f, err := os.Open(name)
+	g.Expect(err).Should(gomega.Succeed())
+	defer f.Close()
+	entry, err := passwdfile.Lookup(f, "alice")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(argon2id.Compare(entry.Password, "my-password")).Should(gomega.Succeed())
+
+	info, err := os.Stat(name)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(info.Mode().Perm()).Should(gomega.Equal(os.FileMode(0640)))
+
+	// a new file is created
+	exitStatus, _, _ = runTest(false, "dovecot -q -user carol -memory 1024 -file "+filepath.Join(dir, "new"))
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "new"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(data)).Should(gomega.HavePrefix("carol:{ARGON2ID}"))
+}
+
+func TestRunDovecotWithSymlink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "users")
+	g.Expect(ioutil.WriteFile(target, []byte("bob:{PLAIN}secret:1001:1001\n"), 0640)).Should(gomega.Succeed())
+	link := filepath.Join(dir, "passwd")
+	g.Expect(os.Symlink("users", link)).Should(gomega.Succeed())
+
+	exitStatus, _, stderr := runTest(false, "dovecot -q -user alice -memory 1024 -file "+link)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+
+	// the link still points to the same file, which has been updated in place of the link
+	dest, err := os.Readlink(link)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(dest).Should(gomega.Equal("users"))
+
+	data, err := ioutil.ReadFile(target)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(data)).Should(gomega.MatchRegexp(`^bob:\{PLAIN\}secret:1001:1001\nalice:\{ARGON2ID\}`))
+
+	info, err := os.Stat(target)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(info.Mode().Perm()).Should(gomega.Equal(os.FileMode(0640)))
+}
+
+func TestRunDovecotWithoutUser(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "dovecot")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal("a user name is required\n"))
+}
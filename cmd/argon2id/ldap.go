@@ -0,0 +1,105 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/ldif"
+)
+
+// runLDAP prompts for a password and prints an {ARGON2} userPassword value, or an LDIF change record when a DN is
+// given. With -audit, it instead checks the userPassword values of an LDIF file against the parameter policy.
+func runLDAP(stdout, stderr io.Writer, args []string) int {
+	flagset := flag.NewFlagSet(os.Args[0]+" ldap", flag.ExitOnError)
+	flagset.SetOutput(stderr)
+
+	dn := flagset.String("dn", "", "print an LDIF change record replacing the userPassword of this entry")
+	audit := flagset.String("audit", "", "an LDIF file whose userPassword values are checked against the policy")
+	quiet := flagset.Bool("q", false, "do not print the "+prompt+" text")
+	timeComplexity := flagset.Int("time", 0, "time complexity when generating hash, or the minimum when auditing")
+	memoryComplexity := flagset.Int("memory", 0, "memory complexity when generating hash, or the minimum when auditing")
+	numThreads := flagset.Int("threads", 0, "number of threads to use when generating hash, or the minimum when auditing")
+	preset := flagset.String("preset", "", "named parameters to start from, overridden by the other flags: "+presetNames())
+	flagset.Parse(args)
+
+	params, err := hashParams(*preset, *timeComplexity, *memoryComplexity, *numThreads, 0)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	if *audit != "" {
+		return runLDAPAudit(stdout, stderr, *audit, params)
+	}
+
+	pwBytes, ok := promptPassword(stdout, stderr, *quiet)
+	if !ok {
+		return exitStatusError
+	}
+
+	defer wipe(pwBytes)
+
+	hashedPassword, err := argon2id.Hasher{Params: params, Format: argon2id.FormatLDAP, Progress: progressBar(stderr)}.HashBytes(pwBytes)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not hash password: %v\n", err)
+		return exitStatusError
+	}
+
+	if *dn == "" {
+		fmt.Fprintf(stdout, "%s: %s\n", ldif.UserPassword, hashedPassword)
+		return exitStatusNormal
+	}
+
+	if err := ldif.WriteUserPassword(stdout, *dn, hashedPassword); err != nil {
+		fmt.Fprintf(stderr, "could not write LDIF: %v\n", err)
+		return exitStatusError
+	}
+
+	return exitStatusNormal
+}
+
+func runLDAPAudit(stdout, stderr io.Writer, name string, policy argon2id.Params) int {
+	f, err := os.Open(name)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not open LDIF: %v\n", err)
+		return exitStatusError
+	}
+	defer f.Close()
+
+	findings, err := ldif.Audit(f, policy)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not audit LDIF: %v\n", err)
+		return exitStatusError
+	}
+
+	for _, finding := range findings {
+		fmt.Fprintln(stdout, finding)
+	}
+
+	if len(findings) > 0 {
+		return exitStatusAuditFindings
+	}
+
+	return exitStatusNormal
+}
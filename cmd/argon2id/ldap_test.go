@@ -0,0 +1,91 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestRunLDAP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(true, "ldap -time 2 -memory 1024 -threads 1")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`^userPassword: \{ARGON2\}\$argon2id\$v=19\$m=1024,t=2,p=1\$\S+\n$`))
+
+	hashedPassword := strings.TrimSpace(strings.TrimPrefix(stdout, "userPassword: "))
+	g.Expect(argon2id.Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+}
+
+func TestRunLDAPWithDN(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(true, "ldap -dn uid=alice,dc=example,dc=com -memory 1024")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`^dn: uid=alice,dc=example,dc=com\nchangetype: modify\nreplace: userPassword\nuserPassword: \{ARGON2\}\S+\n-\n\n$`))
+}
+
+func TestRunLDAPAudit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "export.ldif")
+	g.Expect(ioutil.WriteFile(name, []byte("dn: uid=alice,dc=example,dc=com\n"+
+		"userPassword: {ARGON2}"+testPHCHash+"\n"+
+		"\n"+
+		"dn: uid=bob,dc=example,dc=com\n"+
+		"userPassword: {SSHA}c2VjcmV0\n"), 0600)).Should(gomega.Succeed())
+
+	exitStatus, stdout, stderr := runTest(false, "ldap -audit "+name)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusAuditFindings))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.Equal("line 4: uid=bob,dc=example,dc=com: uses the {SSHA} scheme\n"))
+
+	exitStatus, stdout, _ = runTest(false, "ldap -audit "+name+" -time 5")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusAuditFindings))
+	g.Expect(stdout).Should(gomega.HavePrefix("line 1: uid=alice,dc=example,dc=com: t=1 is below 5\n"))
+
+	// testPHCHash has t=1, m=65536, p=4, which is below the second RFC 9106 recommendation
+	exitStatus, stdout, _ = runTest(false, "ldap -audit "+name+" -preset rfc9106-second")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusAuditFindings))
+	g.Expect(stdout).Should(gomega.HavePrefix("line 1: uid=alice,dc=example,dc=com: t=1 is below 3\n"))
+
+	exitStatus, stdout, stderr = runTest(false, "ldap -audit "+filepath.Join(dir, "missing.ldif"))
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.HavePrefix("could not open LDIF: "))
+}
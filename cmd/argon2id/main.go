@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/synacor/argon2id"
@@ -34,6 +36,7 @@ const (
 	exitStatusNormal = iota
 	exitStatusError
 	exitStatusMismatchHashAndPassword
+	exitStatusAuditFindings
 )
 
 func main() {
@@ -44,6 +47,33 @@ func main() {
 // runCommand will return an exit status that can be used with "os.Exit()". That is, "0" signifies success
 // and a non-"0" value signifies error.
 func runCommand(stdout, stderr io.Writer) int {
+	if filepath.Base(os.Args[0]) == referenceName {
+		return runReference(stdout, stderr, os.Args[1:])
+	}
+
+	// the same ARGON2ID_* settings as services using the package, so that "0" values mean the same here
+	if err := argon2id.LoadDefaults(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			return runServe(stdout, stderr, os.Args[2:])
+		case "convert":
+			return runConvert(stdout, stderr, os.Args[2:])
+		case "shadow":
+			return runShadow(stdout, stderr, os.Args[2:])
+		case "ldap":
+			return runLDAP(stdout, stderr, os.Args[2:])
+		case "dovecot":
+			return runDovecot(stdout, stderr, os.Args[2:])
+		case referenceName:
+			return runReference(stdout, stderr, os.Args[2:])
+		}
+	}
+
 	flagset := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	flagset.SetOutput(stderr)
 
@@ -54,6 +84,7 @@ func runCommand(stdout, stderr io.Writer) int {
 	memoryComplexity := flagset.Int("memory", 0, "memory complexity when generating hash")
 	numThreads := flagset.Int("threads", 0, "number of threads to use when generating hash")
 	keyLen := flagset.Int("keylen", 0, "keyLen when generating hash")
+	preset := flagset.String("preset", "", "named parameters to start from, overridden by the other flags: "+presetNames())
 	help := flagset.Bool("h", false, "show help information")
 	flagset.Parse(os.Args[1:])
 
@@ -62,29 +93,20 @@ func runCommand(stdout, stderr io.Writer) int {
 		return exitStatusError
 	}
 
-	if !*quiet {
-		fmt.Fprintf(stdout, prompt)
-	}
-
-	pwBytes, err := readPassword(int(syscall.Stdin))
+	params, err := hashParams(*preset, *timeComplexity, *memoryComplexity, *numThreads, *keyLen)
 	if err != nil {
-		fmt.Fprintf(stderr, "could not read password: %v\n", err)
+		fmt.Fprintln(stderr, err.Error())
 		return exitStatusError
 	}
 
-	if len(pwBytes) == 0 {
-		fmt.Fprintf(stderr, "a password is required\n")
+	pwBytes, ok := promptPassword(stdout, stderr, *quiet)
+	if !ok {
 		return exitStatusError
 	}
-
-	if !*quiet {
-		fmt.Fprintln(stdout)
-	}
-
-	password := string(pwBytes)
+	defer wipe(pwBytes)
 
 	if len(*compareHashedPassword) > 0 {
-		if err := argon2id.Compare(*compareHashedPassword, password); err != nil {
+		if err := argon2id.CompareBytes(*compareHashedPassword, pwBytes); err != nil {
 			fmt.Fprintln(stderr, err.Error())
 			if err == argon2id.ErrMismatchedHashAndPassword {
 				return exitStatusMismatchHashAndPassword
@@ -97,7 +119,7 @@ func runCommand(stdout, stderr io.Writer) int {
 		return exitStatusNormal
 	}
 
-	hashedPassword, err := argon2id.HashPassword(password, uint32(*timeComplexity), uint32(*memoryComplexity), uint8(*numThreads), uint32(*keyLen))
+	hashedPassword, err := argon2id.Hasher{Params: params, Progress: progressBar(stderr)}.HashBytes(pwBytes)
 	if err != nil {
 		fmt.Fprintf(stderr, "could not hash password: %v", err)
 		return exitStatusError
@@ -111,10 +133,79 @@ func runCommand(stdout, stderr io.Writer) int {
 	return exitStatusNormal
 }
 
+// promptPassword will prompt for and read a non-empty password. If it returns false, the error has already been
+// written to stderr. Callers wipe the password once they are done with it.
+func promptPassword(stdout, stderr io.Writer, quiet bool) ([]byte, bool) {
+	if !quiet {
+		fmt.Fprintf(stdout, prompt)
+	}
+
+	pwBytes, err := readPassword(int(syscall.Stdin))
+	if err != nil {
+		fmt.Fprintf(stderr, "could not read password: %v\n", err)
+		return nil, false
+	}
+
+	if len(pwBytes) == 0 {
+		fmt.Fprintf(stderr, "a password is required\n")
+		return nil, false
+	}
+
+	if !quiet {
+		fmt.Fprintln(stdout)
+	}
+
+	return pwBytes, true
+}
+
+// wipe will overwrite b with zeros, so the password does not linger in memory until the process exits
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// hashParams will return the named preset, if any, with the non-zero flag values applied on top of it
+func hashParams(preset string, time, memory, threads, keyLen int) (argon2id.Params, error) {
+	var p argon2id.Params
+	if preset != "" {
+		var err error
+		if p, err = argon2id.ParsePreset(preset); err != nil {
+			return p, err
+		}
+	}
+
+	if time != 0 {
+		p.Time = uint32(time)
+	}
+	if memory != 0 {
+		p.Memory = uint32(memory)
+	}
+	if threads != 0 {
+		p.Threads = uint8(threads)
+	}
+	if keyLen != 0 {
+		p.KeyLen = uint32(keyLen)
+	}
+
+	return p, nil
+}
+
+func presetNames() string {
+	return strings.Join(argon2id.Presets(), ", ")
+}
+
 func usage(flagset *flag.FlagSet, stderr io.Writer) {
 	fmt.Fprintf(stderr, "usage of %s...\n", os.Args[0])
 	fmt.Fprintf(stderr, "         %s # prompt for password, output a hash of the password\n", os.Args[0])
-	fmt.Fprintf(stderr, "         %s -c <hashed-password> [-n] [-q] [-time <time-complexity>] [-memory <memory-complexity>] [-threads <num-threads>] [-keylen <key-length>] # compare the password (via prompt) to the hashed-password\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s -c <hashed-password> [-n] [-q] [-preset <name>] [-time <time-complexity>] [-memory <memory-complexity>] [-threads <num-threads>] [-keylen <key-length>] # compare the password (via prompt) to the hashed-password\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s serve [-listen <address>] [-concurrency <num-operations>] [-memory-budget <kib>] [-pool] [-workers <num-goroutines>] [-metrics-listen <host:port>] # run the hashing daemon\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s convert [-to <format>] [<hashed-password>...] # convert hashed passwords (or lines of stdin) to another format\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s shadow -user <name> [-q] [-preset <name>] [-time <time-complexity>] [-memory <memory-complexity>] [-threads <num-threads>] # prompt for password, output an /etc/shadow line\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s ldap [-dn <dn>] [-q] [-preset <name>] [-time <time-complexity>] [-memory <memory-complexity>] [-threads <num-threads>] # prompt for password, output an {ARGON2} userPassword value or LDIF change record\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s ldap -audit <file.ldif> [-time <min-time>] [-memory <min-memory>] [-threads <min-threads>] # report userPassword values that are not {ARGON2} or are below the policy\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s dovecot -user <name> [-uid <uid>] [-gid <gid>] [-home <dir>] [-extra <fields>] [-file <passwd-file>] [-q] [-preset <name>] [-time <time-complexity>] [-memory <memory-complexity>] [-threads <num-threads>] # prompt for password, output or update a Dovecot passwd-file line\n", os.Args[0])
+	fmt.Fprintf(stderr, "         %s argon2 <salt> -id [-t <iterations>] [-m <log2-memory> | -k <memory>] [-p <parallelism>] [-l <hash-length>] [-e|-r] # behave like the reference argon2 tool, also when run as argon2\n", os.Args[0])
 
 	flagset.PrintDefaults()
 }
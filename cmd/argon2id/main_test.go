@@ -139,6 +139,68 @@ func TestRunCommandWithCustomComplexity(t *testing.T) {
 	g.Expect(len(stderr)).Should(gomega.Equal(0))
 }
 
+func TestRunCommandWithPreset(t *testing.T) {
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(true, "-n -preset owasp-5 -memory 1024")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`\$5,1024,1\$`))
+	g.Expect(argon2id.GetParams(stdout)).Should(gomega.Equal(argon2id.Params{Time: 5, Memory: 1024, Threads: 1, KeyLen: 32}))
+	g.Expect(len(stderr)).Should(gomega.Equal(0))
+}
+
+func TestRunCommandWithUnknownPreset(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "-preset fast")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal("synacor/argon2id: unknown preset \"fast\"\n"))
+}
+
+func TestRunCommandWithDefaultsFromEnvironment(t *testing.T) {
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+	defer argon2id.SetDefaults(argon2id.Params{})
+
+	g := gomega.NewGomegaWithT(t)
+
+	os.Setenv(argon2id.EnvMemory, "1024")
+	exitStatus, stdout, stderr := runTest(true, "-n -time 2")
+	os.Unsetenv(argon2id.EnvMemory)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`\$2,1024,4\$`))
+	g.Expect(len(stderr)).Should(gomega.Equal(0))
+
+	os.Setenv(argon2id.EnvTime, "soon")
+	exitStatus, stdout, stderr = runTest(false, "-n")
+	os.Unsetenv(argon2id.EnvTime)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal("synacor/argon2id: ARGON2ID_TIME: invalid time \"soon\"\n"))
+}
+
+func TestRunCommandWipesPassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	pw := []byte("my-password")
+	oldFn := readPassword
+	defer func() { readPassword = oldFn }()
+	readPassword = func(int) ([]byte, error) { return pw, nil }
+
+	exitStatus, stdout, _ := runTest(true, "-n -memory 1024")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(pw).Should(gomega.Equal(make([]byte, len("my-password"))))
+
+	pw = []byte("my-password")
+	exitStatus, _, _ = runTest(true, "-c "+stdout)
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(pw).Should(gomega.Equal(make([]byte, len("my-password"))))
+}
+
 func TestRunCommandCompare(t *testing.T) {
 	reset := mockReadPassword([]byte("my-password"), nil)
 	defer reset()
@@ -0,0 +1,58 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/synacor/argon2id"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const progressBarWidth = 40
+
+// isTerminal reports whether w is a terminal
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// progressBar will return a ProgressFunc that draws a progress bar on stderr and erases it once the computation is
+// finished, or nil if stderr is not a terminal
+func progressBar(stderr io.Writer) argon2id.ProgressFunc {
+	if !isTerminal(stderr) {
+		return nil
+	}
+
+	return func(p argon2id.Progress) {
+		done, total := p.Done()
+		filled := int(done * progressBarWidth / total)
+		line := fmt.Sprintf("[%s%s] %3d%% pass %d/%d", strings.Repeat("#", filled), strings.Repeat(" ", progressBarWidth-filled), done*100/total, p.Pass, p.Params.Time)
+
+		if done == total {
+			fmt.Fprintf(stderr, "\r%s\r", strings.Repeat(" ", len(line)))
+			return
+		}
+
+		fmt.Fprintf(stderr, "\r%s", line)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func mockIsTerminal(terminal bool) func() {
+	oldFn := isTerminal
+	isTerminal = func(io.Writer) bool { return terminal }
+	return func() { isTerminal = oldFn }
+}
+
+func TestProgressBar(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(progressBar(bytes.NewBuffer(nil))).Should(gomega.BeNil())
+
+	defer mockIsTerminal(true)()
+	stderr := bytes.NewBuffer(nil)
+	progress := progressBar(stderr)
+
+	params := argon2id.Params{Time: 2}
+	progress(argon2id.Progress{Params: params, Pass: 1, Slice: 1})
+	g.Expect(stderr.String()).Should(gomega.Equal("\r[#####                                   ]  12% pass 1/2"))
+
+	stderr.Reset()
+	progress(argon2id.Progress{Params: params, Pass: 2, Slice: 2})
+	g.Expect(stderr.String()).Should(gomega.Equal("\r[##############################          ]  75% pass 2/2"))
+
+	// the bar is erased once finished
+	stderr.Reset()
+	progress(argon2id.Progress{Params: params, Pass: 2, Slice: 4})
+	g.Expect(stderr.String()).Should(gomega.Equal("\r" + strings.Repeat(" ", 56) + "\r"))
+}
+
+func TestRunCommandWithProgressBar(t *testing.T) {
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+	defer mockIsTerminal(true)()
+
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(true, "-n -time 2 -memory 1024 -threads 2")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(argon2id.IsHashedPassword(stdout)).Should(gomega.BeTrue())
+	g.Expect(stderr).Should(gomega.HavePrefix("\r[#####"))
+	g.Expect(stderr).Should(gomega.ContainSubstring("pass 2/2"))
+	g.Expect(stderr).Should(gomega.HaveSuffix(strings.Repeat(" ", 56) + "\r"))
+}
@@ -24,8 +24,9 @@ func mockReadPassword(pw []byte, err error) (reset func()) {
 		readPassword = oldFn
 	}
 
+	// a copy each time, since the command wipes the password it read
 	readPassword = func(int) ([]byte, error) {
-		return pw, err
+		return append([]byte(nil), pw...), err
 	}
 	return
 }
@@ -0,0 +1,169 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/synacor/argon2id"
+)
+
+// referenceName is the name of the reference implementation's command line tool. When this binary is installed or
+// linked under that name, it behaves like that tool so that scripts written for it keep working.
+const referenceName = "argon2"
+
+// The limits checked by the reference implementation, with its error messages
+const (
+	referenceMinSaltLen = 8
+	referenceMinOutLen  = 4
+)
+
+// runReference mimics "argon2 salt [-id] [-t iterations] [-m log2(memory) | -k memory] [-p parallelism] [-l length]
+// [-e|-r]", reading the password from stdin without stripping a trailing newline. Only Argon2id and version 13 (19)
+// are supported, so -id must be given where the reference tool would default to Argon2i.
+func runReference(stdout, stderr io.Writer, args []string) int {
+	if len(args) == 0 || args[0] == "-h" {
+		referenceUsage(stderr)
+		return exitStatusError
+	}
+
+	salt := args[0]
+
+	flagset := flag.NewFlagSet(referenceName, flag.ContinueOnError)
+	flagset.SetOutput(stderr)
+	flagset.Usage = func() { referenceUsage(stderr) }
+
+	typeI := flagset.Bool("i", false, "")
+	typeD := flagset.Bool("d", false, "")
+	typeID := flagset.Bool("id", false, "")
+	iterations := flagset.Uint("t", 3, "")
+	logMemory := flagset.Uint("m", 12, "")
+	memory := flagset.Uint("k", 0, "")
+	parallelism := flagset.Uint("p", 1, "")
+	length := flagset.Uint("l", 32, "")
+	encodedOnly := flagset.Bool("e", false, "")
+	rawOnly := flagset.Bool("r", false, "")
+	version := flagset.Uint("v", 13, "")
+	if err := flagset.Parse(args[1:]); err != nil {
+		return exitStatusError
+	}
+
+	fatal := func(msg string) int {
+		fmt.Fprintf(stderr, "Error: %s\n", msg)
+		return exitStatusError
+	}
+
+	if *typeI || *typeD || !*typeID {
+		return fatal("only Argon2id is supported, use -id")
+	}
+
+	if *version != 13 {
+		return fatal("only version 13 is supported")
+	}
+
+	mCost := *memory
+	if mCost == 0 {
+		if *logMemory < 1 || *logMemory > 31 {
+			return fatal("bad numeric input")
+		}
+		mCost = 1 << *logMemory
+	}
+
+	switch {
+	case len(salt) < referenceMinSaltLen:
+		return fatal("Salt is too short")
+	case *iterations < 1 || *iterations > 1<<32-1:
+		return fatal("Time cost is too small")
+	case *parallelism < 1 || *parallelism > 255:
+		return fatal("Too few lanes")
+	case mCost < 8*(*parallelism) || mCost > 1<<32-1:
+		return fatal("Memory cost is too small")
+	case *length < referenceMinOutLen || *length > 1<<32-1:
+		return fatal("Output is too short")
+	}
+
+	password, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return fatal(err.Error())
+	}
+	if len(password) == 0 {
+		return fatal("no password read")
+	}
+	defer wipe(password)
+
+	if !*encodedOnly && !*rawOnly {
+		fmt.Fprintf(stdout, "Type:\t\tArgon2id\n")
+		fmt.Fprintf(stdout, "Iterations:\t%d\n", *iterations)
+		fmt.Fprintf(stdout, "Memory:\t\t%d KiB\n", mCost)
+		fmt.Fprintf(stdout, "Parallelism:\t%d\n", *parallelism)
+	}
+
+	p := argon2id.Params{Time: uint32(*iterations), Memory: uint32(mCost), Threads: uint8(*parallelism), KeyLen: uint32(*length)}
+	start := time.Now()
+	key := argon2id.KeyWithProgress(password, []byte(salt), p, progressBar(stderr))
+	elapsed := time.Since(start)
+	defer wipe(key)
+
+	encoded := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s", p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString([]byte(salt)), base64.RawStdEncoding.EncodeToString(key))
+
+	if *encodedOnly {
+		fmt.Fprintln(stdout, encoded)
+	}
+	if *rawOnly {
+		fmt.Fprintln(stdout, hex.EncodeToString(key))
+	}
+	if *encodedOnly || *rawOnly {
+		return exitStatusNormal
+	}
+
+	fmt.Fprintf(stdout, "Hash:\t\t%s\n", hex.EncodeToString(key))
+	fmt.Fprintf(stdout, "Encoded:\t%s\n", encoded)
+	fmt.Fprintf(stdout, "%2.3f seconds\n", elapsed.Seconds())
+
+	if err := argon2id.CompareBytes(encoded, password); err != nil {
+		return fatal(err.Error())
+	}
+
+	fmt.Fprintf(stdout, "Verification ok\n")
+	return exitStatusNormal
+}
+
+func referenceUsage(stderr io.Writer) {
+	fmt.Fprintf(stderr, "Usage:  %s [-h] salt -id [-t iterations] [-m log2(memory in KiB) | -k memory in KiB] [-p parallelism] [-l hash length] [-e|-r] [-v 13]\n", referenceName)
+	fmt.Fprintf(stderr, "\tPassword is read from stdin\n")
+	fmt.Fprintf(stderr, "Parameters:\n")
+	fmt.Fprintf(stderr, "\tsalt\t\tThe salt to use, at least 8 characters\n")
+	fmt.Fprintf(stderr, "\t-id\t\tUse Argon2id, the only supported type\n")
+	fmt.Fprintf(stderr, "\t-t N\t\tSets the number of iterations to N (default = 3)\n")
+	fmt.Fprintf(stderr, "\t-m N\t\tSets the memory usage of 2^N KiB (default 12)\n")
+	fmt.Fprintf(stderr, "\t-k N\t\tSets the memory usage of N KiB (default 4096)\n")
+	fmt.Fprintf(stderr, "\t-p N\t\tSets parallelism to N threads (default 1)\n")
+	fmt.Fprintf(stderr, "\t-l N\t\tSets hash output length to N bytes (default 32)\n")
+	fmt.Fprintf(stderr, "\t-e\t\tOutput only encoded hash\n")
+	fmt.Fprintf(stderr, "\t-r\t\tOutput only the raw bytes of the hash\n")
+	fmt.Fprintf(stderr, "\t-v 13\t\tArgon2 version, only 13 is supported\n")
+	fmt.Fprintf(stderr, "\n\t-h\t\tPrint %s usage\n", referenceName)
+}
@@ -0,0 +1,115 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+const (
+	referenceHex     = "09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"
+	referenceEncoded = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+)
+
+func TestRunReference(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockStdin("password")
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(false, "argon2 somesalt -id -t 2 -m 16 -p 1")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`^Type:\t\tArgon2id\n` +
+		`Iterations:\t2\n` +
+		`Memory:\t\t65536 KiB\n` +
+		`Parallelism:\t1\n` +
+		`Hash:\t\t` + referenceHex + `\n` +
+		`Encoded:\t\$argon2id\$v=19\$m=65536,t=2,p=1\$c29tZXNhbHQ\$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc\n` +
+		`\d+\.\d{3} seconds\n` +
+		`Verification ok\n$`))
+}
+
+func TestRunReferenceOutputOnly(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockStdin("password")
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(false, "argon2 somesalt -id -t 2 -k 65536 -r")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.Equal(referenceHex + "\n"))
+
+	reset = mockStdin("password")
+	defer reset()
+
+	exitStatus, stdout, _ = runTest(false, "argon2 somesalt -id -t 2 -m 16 -e")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout).Should(gomega.Equal(referenceEncoded + "\n"))
+}
+
+func TestRunReferenceAsArgon2(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockStdin("password")
+	defer reset()
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"/usr/local/bin/argon2", "somesalt", "-id", "-t", "2", "-m", "16", "-e"}
+
+	stdout := bytes.NewBuffer(nil)
+	g.Expect(runCommand(stdout, ioutil.Discard)).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout.String()).Should(gomega.Equal(referenceEncoded + "\n"))
+}
+
+func TestRunReferenceFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for args, msg := range map[string]string{
+		"argon2 somesalt -t 2":                "Error: only Argon2id is supported, use -id\n",
+		"argon2 somesalt -i -t 2":             "Error: only Argon2id is supported, use -id\n",
+		"argon2 somesalt -id -v 10":           "Error: only version 13 is supported\n",
+		"argon2 short -id":                    "Error: Salt is too short\n",
+		"argon2 somesalt -id -t 0":            "Error: Time cost is too small\n",
+		"argon2 somesalt -id -p 0":            "Error: Too few lanes\n",
+		"argon2 somesalt -id -k 8 -p 2":       "Error: Memory cost is too small\n",
+		"argon2 somesalt -id -m 40":           "Error: bad numeric input\n",
+		"argon2 somesalt -id -l 3":            "Error: Output is too short\n",
+		"argon2 somesalt -id -m 10 -p 1 -l 4": "Error: no password read\n",
+	} {
+		reset := mockStdin("")
+		exitStatus, stdout, stderr := runTest(false, args)
+		reset()
+
+		g.Expect(exitStatus).Should(gomega.Equal(exitStatusError), args)
+		g.Expect(stdout).Should(gomega.Equal(""), args)
+		g.Expect(stderr).Should(gomega.Equal(msg), args)
+	}
+
+	exitStatus, _, stderr := runTest(false, "argon2")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stderr).Should(gomega.HavePrefix("Usage:  argon2 [-h] salt -id"))
+}
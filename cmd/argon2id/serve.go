@@ -0,0 +1,180 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"expvar"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/internal/protocol"
+	"github.com/synacor/argon2id/metrics"
+	"github.com/synacor/argon2id/server"
+)
+
+// shutdownSignal returns a channel that receives when the daemon should exit
+var shutdownSignal = func() <-chan os.Signal {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	return c
+}
+
+// runServe runs the hashing daemon until it receives SIGINT or SIGTERM
+func runServe(stdout, stderr io.Writer, args []string) int {
+	flagset := flag.NewFlagSet(os.Args[0]+" serve", flag.ExitOnError)
+	flagset.SetOutput(stderr)
+
+	listen := flagset.String("listen", protocol.DefaultAddress, "address to listen on, either unix:<path> or tcp:<loopback-host>:<port>")
+	socketMode := flagset.String("socket-mode", "0600", "permissions of the Unix socket, in octal")
+	maxConcurrent := flagset.Int("concurrency", 0, "maximum number of operations to run at once (default is the number of CPUs)")
+	memoryBudget := flagset.Uint64("memory-budget", 0, "maximum Argon2 memory in KiB to use at once; operations that need more are refused (default 262144)")
+	pool := flagset.Bool("pool", false, "keep Argon2 memory, up to the memory budget, for reuse between operations")
+	workers := flagset.Int("workers", 0, "number of goroutines shared by all operations to compute Argon2 lanes (default is one per lane of each operation)")
+	metricsListen := flagset.String("metrics-listen", "", "host:port to serve Prometheus metrics (/metrics) and expvar (/debug/vars) on")
+	flagset.Parse(args)
+
+	network, addr, err := protocol.SplitAddress(*listen)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	var l net.Listener
+	if network == "unix" {
+		mode, perr := strconv.ParseUint(*socketMode, 8, 32)
+		if perr != nil || os.FileMode(mode)&^os.ModePerm != 0 {
+			fmt.Fprintf(stderr, "invalid socket mode %q\n", *socketMode)
+			return exitStatusError
+		}
+		l, err = listenUnix(addr, os.FileMode(mode))
+	} else {
+		l, err = server.Listen(*listen)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "could not listen on %s: %v\n", *listen, err)
+		return exitStatusError
+	}
+
+	options := argon2id.ComputeOptions{Workers: *workers}
+	if *pool {
+		options.PoolMemory = *memoryBudget
+		if options.PoolMemory == 0 {
+			options.PoolMemory = server.DefaultMemoryBudget
+		}
+	}
+	if options != (argon2id.ComputeOptions{}) {
+		argon2id.SetComputeOptions(options)
+	}
+
+	errorLog := log.New(stderr, "", log.LstdFlags)
+	s := &server.Server{
+		MaxConcurrent: *maxConcurrent,
+		MemoryBudget:  *memoryBudget,
+		ErrorLog:      errorLog,
+	}
+
+	collector := metrics.Install()
+	if *metricsListen != "" {
+		ml, err := net.Listen("tcp", *metricsListen)
+		if err != nil {
+			l.Close()
+			fmt.Fprintf(stderr, "could not listen on %s: %v\n", *metricsListen, err)
+			return exitStatusError
+		}
+		defer ml.Close()
+
+		mux := http.NewServeMux()
+		collector.Register(mux, "/metrics")
+		mux.Handle("/debug/vars", expvar.Handler())
+		go (&http.Server{Handler: mux, ErrorLog: errorLog}).Serve(ml)
+		fmt.Fprintf(stdout, "serving metrics on %s\n", ml.Addr())
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	fmt.Fprintf(stdout, "listening on %s\n", *listen)
+
+	select {
+	case <-shutdownSignal():
+		s.Close()
+		<-done
+		return exitStatusNormal
+
+	case err := <-done:
+		fmt.Fprintf(stderr, "could not serve: %v\n", err)
+		return exitStatusError
+	}
+}
+
+// listenUnix will listen on the Unix socket at path with the given permissions. The socket is created under a
+// temporary name and only renamed into place once it has its permissions, so no client can connect before then.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// a stale socket file left by a daemon that did not shut down cleanly would make listening fail, but a socket that
+	// still accepts connections belongs to a running daemon
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if c, err := net.Dial("unix", path); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("%s is in use by a running daemon", path)
+		}
+		os.Remove(path)
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"."+strconv.Itoa(os.Getpid()))
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	l.SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmp, mode); err != nil {
+		l.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	return &unixListener{UnixListener: l, path: path}, nil
+}
+
+// unixListener removes its socket file when it is closed, as net.UnixListener does for the name it was created with
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
@@ -0,0 +1,199 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestRunServe(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	signals := make(chan os.Signal, 1)
+	oldFn := shutdownSignal
+	defer func() { shutdownSignal = oldFn }()
+	shutdownSignal = func() <-chan os.Signal { return signals }
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	done := make(chan int)
+	go func() { done <- runServe(stdout, stderr, []string{"-listen", "tcp:127.0.0.1:0"}) }()
+
+	signals <- syscall.SIGTERM
+	g.Expect(<-done).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stdout.String()).Should(gomega.Equal("listening on tcp:127.0.0.1:0\n"))
+	g.Expect(stderr.Len()).Should(gomega.Equal(0))
+}
+
+func TestRunServeUnixSocket(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "argon2id")
+	g.Expect(err).Should(gomega.Succeed())
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "argon2id.sock")
+
+	signals := make(chan os.Signal, 1)
+	oldFn := shutdownSignal
+	defer func() { shutdownSignal = oldFn }()
+	shutdownSignal = func() <-chan os.Signal { return signals }
+
+	for _, tc := range []struct {
+		args []string
+		mode os.FileMode
+	}{
+		{nil, 0600},
+		{[]string{"-socket-mode", "660"}, 0660},
+	} {
+		tc := tc
+		stdout := &lockedBuffer{}
+		done := make(chan int)
+		go func() {
+			done <- runServe(stdout, ioutil.Discard, append([]string{"-listen", "unix:" + path}, tc.args...))
+		}()
+		g.Eventually(stdout.String).Should(gomega.HavePrefix("listening on"))
+
+		info, err := os.Stat(path)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(info.Mode() & os.ModeSocket).ShouldNot(gomega.BeZero())
+		g.Expect(info.Mode().Perm()).Should(gomega.Equal(tc.mode))
+
+		// a second daemon must not take over the socket of a running one
+		stderr := bytes.NewBuffer(nil)
+		g.Expect(runServe(ioutil.Discard, stderr, []string{"-listen", "unix:" + path})).Should(gomega.Equal(exitStatusError))
+		g.Expect(stderr.String()).Should(gomega.ContainSubstring("in use by a running daemon"))
+		c, err := net.Dial("unix", path)
+		g.Expect(err).Should(gomega.Succeed())
+		c.Close()
+
+		signals <- syscall.SIGTERM
+		g.Expect(<-done).Should(gomega.Equal(exitStatusNormal))
+		_, err = os.Stat(path)
+		g.Expect(os.IsNotExist(err)).Should(gomega.BeTrue())
+	}
+
+	// a stale socket that nothing listens on is replaced
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	g.Expect(err).Should(gomega.Succeed())
+	l.SetUnlinkOnClose(false)
+	l.Close()
+
+	signals <- syscall.SIGTERM
+	g.Expect(runServe(ioutil.Discard, ioutil.Discard, []string{"-listen", "unix:" + path})).Should(gomega.Equal(exitStatusNormal))
+
+	exitStatus, _, stderr := runTest(false, "serve -listen unix:"+path+" -socket-mode 9")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stderr).Should(gomega.Equal("invalid socket mode \"9\"\n"))
+}
+
+func TestRunServeWithComputeOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer argon2id.SetComputeOptions(argon2id.ComputeOptions{})
+
+	signals := make(chan os.Signal, 1)
+	oldFn := shutdownSignal
+	defer func() { shutdownSignal = oldFn }()
+	shutdownSignal = func() <-chan os.Signal { return signals }
+
+	signals <- syscall.SIGTERM
+	g.Expect(runServe(ioutil.Discard, ioutil.Discard, []string{"-listen", "tcp:127.0.0.1:0", "-memory-budget", "1024", "-pool"})).Should(gomega.Equal(exitStatusNormal))
+
+	// the pool keeps up to the memory budget
+	argon2id.Key([]byte("password"), []byte("somesalt"), argon2id.Params{Time: 1, Memory: 1024, Threads: 1})
+	g.Expect(argon2id.PooledMemory()).Should(gomega.Equal(uint64(1024)))
+	argon2id.Key([]byte("password"), []byte("somesalt"), argon2id.Params{Time: 1, Memory: 2048, Threads: 1})
+	g.Expect(argon2id.PooledMemory()).Should(gomega.Equal(uint64(1024)))
+	// and the workers compute the same result as a goroutine per lane
+	signals <- syscall.SIGTERM
+	g.Expect(runServe(ioutil.Discard, ioutil.Discard, []string{"-listen", "tcp:127.0.0.1:0", "-workers", "1"})).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(argon2id.PooledMemory()).Should(gomega.BeZero())
+	g.Expect(argon2id.Compare("$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Succeed())
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunServeWithMetrics(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	signals := make(chan os.Signal, 1)
+	oldFn := shutdownSignal
+	defer func() { shutdownSignal = oldFn }()
+	shutdownSignal = func() <-chan os.Signal { return signals }
+
+	stdout := &lockedBuffer{}
+	done := make(chan int)
+	go func() {
+		done <- runServe(stdout, ioutil.Discard, []string{"-listen", "tcp:127.0.0.1:0", "-metrics-listen", "127.0.0.1:0"})
+	}()
+
+	rx := regexp.MustCompile(`serving metrics on (\S+)`)
+	g.Eventually(stdout.String).Should(gomega.MatchRegexp(rx.String()))
+	addr := rx.FindStringSubmatch(stdout.String())[1]
+
+	for _, path := range []string{"/metrics", "/debug/vars"} {
+		resp, err := http.Get("http://" + addr + path)
+		g.Expect(err).Should(gomega.Succeed())
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		g.Expect(string(body)).Should(gomega.ContainSubstring("argon2id"))
+	}
+
+	signals <- syscall.SIGTERM
+	g.Expect(<-done).Should(gomega.Equal(exitStatusNormal))
+}
+
+func TestRunServeWithBadAddress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "serve -listen tcp:0.0.0.0:0")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal("could not listen on tcp:0.0.0.0:0: synacor/argon2id: tcp addresses must be on the loopback interface\n"))
+
+	exitStatus, _, stderr = runTest(false, "serve -listen bogus")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stderr).Should(gomega.ContainSubstring("must be of the form"))
+}
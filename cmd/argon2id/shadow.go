@@ -0,0 +1,85 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/synacor/argon2id"
+)
+
+// now is replaced in tests so the last change date is predictable
+var now = time.Now
+
+// runShadow prompts for a password and prints an /etc/shadow line for the user with a crypt(3) argon2id hash
+func runShadow(stdout, stderr io.Writer, args []string) int {
+	flagset := flag.NewFlagSet(os.Args[0]+" shadow", flag.ExitOnError)
+	flagset.SetOutput(stderr)
+
+	user := flagset.String("user", "", "the user name for the shadow line")
+	quiet := flagset.Bool("q", false, "do not print the "+prompt+" text")
+	timeComplexity := flagset.Int("time", 0, "time complexity when generating hash")
+	memoryComplexity := flagset.Int("memory", 0, "memory complexity when generating hash")
+	numThreads := flagset.Int("threads", 0, "number of threads to use when generating hash")
+	preset := flagset.String("preset", "", "named parameters to start from, overridden by the other flags: "+presetNames())
+	minDays := flagset.Int("min", 0, "minimum number of days between password changes")
+	maxDays := flagset.Int("max", 99999, "maximum number of days the password is valid")
+	warnDays := flagset.Int("warn", 7, "number of days of warning before the password expires")
+	flagset.Parse(args)
+
+	if *user == "" || strings.ContainsAny(*user, ":\n") {
+		fmt.Fprintf(stderr, "a user name without ':' is required\n")
+		return exitStatusError
+	}
+
+	params, err := hashParams(*preset, *timeComplexity, *memoryComplexity, *numThreads, 0)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return exitStatusError
+	}
+
+	pwBytes, ok := promptPassword(stdout, stderr, *quiet)
+	if !ok {
+		return exitStatusError
+	}
+
+	defer wipe(pwBytes)
+
+	setting, err := argon2id.GenSalt(params)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not hash password: %v\n", err)
+		return exitStatusError
+	}
+
+	hashedPassword, err := argon2id.CryptBytes(pwBytes, setting)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not hash password: %v\n", err)
+		return exitStatusError
+	}
+
+	// name:password:last change:min:max:warn:inactive:expire:reserved, with the last change in days since the epoch
+	lastChange := now().Unix() / (24 * 60 * 60)
+	fmt.Fprintf(stdout, "%s:%s:%d:%d:%d:%d:::\n", *user, hashedPassword, lastChange, *minDays, *maxDays, *warnDays)
+	return exitStatusNormal
+}
@@ -0,0 +1,69 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestRunShadow(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte("my-password"), nil)
+	defer reset()
+
+	oldNow := now
+	defer func() { now = oldNow }()
+	now = func() time.Time { return time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	exitStatus, stdout, stderr := runTest(true, "shadow -user alice -time 2 -memory 1024 -threads 1")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusNormal))
+	g.Expect(stderr).Should(gomega.Equal(""))
+	g.Expect(stdout).Should(gomega.MatchRegexp(`^alice:\$argon2id\$v=19\$m=1024,t=2,p=1\$[^:]+:17956:0:99999:7:::\n$`))
+
+	hashedPassword := strings.Split(stdout, ":")[1]
+	g.Expect(argon2id.Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+	g.Expect(argon2id.Crypt("my-password", hashedPassword)).Should(gomega.Equal(hashedPassword))
+}
+
+func TestRunShadowWithoutUser(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exitStatus, stdout, stderr := runTest(false, "shadow")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(""))
+	g.Expect(stderr).Should(gomega.Equal("a user name without ':' is required\n"))
+}
+
+func TestRunShadowWithoutPassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reset := mockReadPassword([]byte(""), nil)
+	defer reset()
+
+	exitStatus, stdout, stderr := runTest(false, "shadow -user alice")
+	g.Expect(exitStatus).Should(gomega.Equal(exitStatusError))
+	g.Expect(stdout).Should(gomega.Equal(prompt))
+	g.Expect(stderr).Should(gomega.Equal("a password is required\n"))
+}
@@ -0,0 +1,102 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/synacor/argon2id/internal/argon2"
+	xargon2 "golang.org/x/crypto/argon2"
+)
+
+// ComputeOptions controls how the Argon2 computation is carried out by every hash, compare and Key call. None of the
+// options change the result, so hashes made with any options can be compared with any others.
+type ComputeOptions struct {
+	// WipeMemory zeroes the Argon2 working memory, and the other intermediate values that depend on the password,
+	// before it is released to the garbage collector. It costs one extra pass over the memory.
+	WipeMemory bool
+
+	// PoolMemory is the amount of Argon2 working memory, in KiB, that is kept for reuse between computations instead
+	// of being left to the garbage collector. Memory is kept in size classes of up to a quarter more than a hash needs,
+	// and is zeroed before it is kept. Only memory that is not in use counts, so the memory in use is still bounded
+	// by the caller, such as the MemoryBudget of CompareBatch. If "0", memory is not pooled.
+	PoolMemory uint64
+
+	// Workers is the number of goroutines, shared by every computation, that compute the lanes of a hash. A hash
+	// with more threads than Workers has its lanes queued, so its result is unchanged but the CPUs are not
+	// oversubscribed. If "0", every computation starts a goroutine per lane, as golang.org/x/crypto/argon2 does.
+	Workers int
+}
+
+// compute is the installed ComputeOptions together with the state they need
+type compute struct {
+	options ComputeOptions
+	config  argon2.Config
+}
+
+var (
+	computeMu      sync.Mutex
+	computeOptions atomic.Value
+)
+
+// SetComputeOptions will replace the options used for every following Argon2 computation. Memory pooled and workers
+// started under the previous options are released. The zero ComputeOptions uses golang.org/x/crypto/argon2 directly.
+func SetComputeOptions(o ComputeOptions) {
+	c := compute{options: o, config: argon2.Config{Wipe: o.WipeMemory}}
+	if o.PoolMemory > 0 {
+		c.config.Pool = argon2.NewPool(o.PoolMemory)
+	}
+	if o.Workers > 0 {
+		c.config.Workers = argon2.NewWorkers(o.Workers)
+	}
+
+	computeMu.Lock()
+	defer computeMu.Unlock()
+
+	// computations that already loaded the previous options finish on their own goroutine
+	if previous := currentCompute().config.Workers; previous != nil {
+		previous.Stop()
+	}
+	computeOptions.Store(c)
+}
+
+// PooledMemory returns the amount of Argon2 working memory, in KiB, that is currently kept for reuse
+func PooledMemory() uint64 {
+	if pool := currentCompute().config.Pool; pool != nil {
+		return pool.Idle()
+	}
+
+	return 0
+}
+
+func currentCompute() compute {
+	c, _ := computeOptions.Load().(compute)
+	return c
+}
+
+func (c compute) idKey(password, salt []byte, p Params, progress ProgressFunc) []byte {
+	if c.options == (ComputeOptions{}) && progress == nil {
+		return xargon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	}
+
+	config := c.config
+	config.Progress = progress.slices(p)
+	return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, p.KeyLen, config)
+}
@@ -0,0 +1,129 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/hex"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestComputeOptionsWipeMemory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hashedPassword, err := HashPassword("password", 1, 1024, 2, 32)
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+	SetComputeOptions(ComputeOptions{WipeMemory: true})
+	defer SetComputeOptions(ComputeOptions{})
+
+	key := Key([]byte("password"), []byte("somesalt"), Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32})
+	g.Expect(hex.EncodeToString(key)).Should(gomega.Equal("09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"))
+
+	g.Expect(Compare(hashedPassword, "password")).Should(gomega.Succeed())
+	g.Expect(Compare(hashedPassword, "wrong")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	wiped, err := HashPassword("password", 1, 1024, 2, 32)
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+	SetComputeOptions(ComputeOptions{})
+	g.Expect(Compare(wiped, "password")).Should(gomega.Succeed())
+}
+
+func TestComputeOptionsPoolMemory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	SetComputeOptions(ComputeOptions{PoolMemory: 4096})
+	defer SetComputeOptions(ComputeOptions{})
+
+	for i := 0; i < 3; i++ {
+		key := Key([]byte("password"), []byte("somesalt"), Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32})
+		g.Expect(hex.EncodeToString(key)).Should(gomega.Equal("09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"))
+	}
+
+	// 64 MiB is more than the pool keeps, but 1 MiB fits
+	g.Expect(PooledMemory()).Should(gomega.BeZero())
+	Key([]byte("password"), []byte("somesalt"), Params{Time: 1, Memory: 1024, Threads: 2})
+	g.Expect(PooledMemory()).Should(gomega.Equal(uint64(1024)))
+
+	SetComputeOptions(ComputeOptions{})
+	g.Expect(PooledMemory()).Should(gomega.BeZero())
+}
+
+func TestComputeOptionsWorkers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hashedPassword, err := HashPassword("password", 1, 1024, 8, 32)
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+	SetComputeOptions(ComputeOptions{Workers: 2})
+	defer SetComputeOptions(ComputeOptions{})
+
+	g.Expect(Compare(hashedPassword, "password")).Should(gomega.Succeed())
+	key := Key([]byte("password"), []byte("somesalt"), Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32})
+	g.Expect(hex.EncodeToString(key)).Should(gomega.Equal("09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"))
+
+	// replacing the options stops the previous workers
+	goroutines := runtime.NumGoroutine()
+	SetComputeOptions(ComputeOptions{Workers: 1})
+	g.Eventually(runtime.NumGoroutine).Should(gomega.Equal(goroutines - 1))
+	g.Expect(Compare(hashedPassword, "password")).Should(gomega.Succeed())
+}
+
+// benchmarkCompute runs Key concurrently, as a busy server would, and logs the garbage collections it caused
+func benchmarkCompute(b *testing.B, o ComputeOptions) {
+	SetComputeOptions(o)
+	defer SetComputeOptions(ComputeOptions{})
+
+	p := Params{Time: 1, Memory: 16 * 1024, Threads: 1, KeyLen: 32}
+	password, salt := []byte("password"), []byte("somesalt")
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Key(password, salt, p)
+		}
+	})
+	b.StopTimer()
+
+	runtime.ReadMemStats(&after)
+	gcs := after.NumGC - before.NumGC
+	pause := time.Duration(after.PauseTotalNs - before.PauseTotalNs)
+	b.Logf("%d ops: %d GCs, %v total GC pause, %v GC pause/op", b.N, gcs, pause, pause/time.Duration(b.N))
+}
+
+func BenchmarkComputeDefault(b *testing.B) {
+	benchmarkCompute(b, ComputeOptions{})
+}
+
+func BenchmarkComputeWipeMemory(b *testing.B) {
+	benchmarkCompute(b, ComputeOptions{WipeMemory: true})
+}
+
+func BenchmarkComputePoolMemory(b *testing.B) {
+	benchmarkCompute(b, ComputeOptions{PoolMemory: uint64(runtime.GOMAXPROCS(0)) * 16 * 1024})
+}
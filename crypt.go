@@ -0,0 +1,80 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrInvalidSetting is an error when the setting passed to Crypt is not an argon2id setting or hash
+var ErrInvalidSetting = errors.New("synacor/argon2id: the setting is not a valid argon2id crypt setting")
+
+// GenSalt will return a crypt(3) setting string for Crypt, of the form "$argon2id$v=19$m=memory,t=time,p=threads$salt",
+// with a new random salt. "0" values in p are replaced by their sane defaults. The key length is not part of the
+// setting; Crypt always produces a 32 byte hash for a setting without one.
+func GenSalt(p Params) (string, error) {
+	p = p.withDefaults()
+
+	salt, err := generateSalt()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s", phcPrefix, argon2.Version, p.Memory, p.Time, p.Threads, stdEncoding.EncodeToString(salt)), nil
+}
+
+// Crypt will hash the password with the parameters and salt in setting and return the result in the PHC format, as
+// crypt(3) does for argon2id in libxcrypt and glibc. The setting is either a string from GenSalt or a complete hash;
+// in the latter case, the hash part is ignored apart from its length, so a password can be verified by checking that
+// Crypt(password, hashedPassword) == hashedPassword. Prefer Compare for verification, as it compares in constant time.
+func Crypt(password, setting string) (string, error) {
+	pw := []byte(password)
+	defer wipe(pw)
+	return CryptBytes(pw, setting)
+}
+
+// CryptBytes is Crypt for a password held in a byte slice, which is not copied
+func CryptBytes(password []byte, setting string) (hashedPassword string, err error) {
+	match := rxPHC.FindStringSubmatch(setting)
+	if match == nil {
+		return "", ErrInvalidSetting
+	}
+
+	h, err := newHashedFromPHCMatch(match)
+	if err != nil {
+		return "", err
+	}
+
+	// crypt(3) is deterministic, so a setting without a hash always gets the built-in key length, never one set
+	// with SetDefaults or the environment
+	p := h.params()
+	if p.KeyLen == 0 {
+		p.KeyLen = defaultKeyLen
+	}
+
+	start := now()
+	defer func() { observe(OperationHash, start, p, FormatPHC, err) }()
+
+	h.hash = deriveKey(password, h.salt, p, nil)
+	defer h.wipe()
+	return h.encode(), nil
+}
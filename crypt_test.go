@@ -0,0 +1,101 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestGenSalt(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	setting, err := GenSalt(Params{Time: 2, Memory: 1024, Threads: 1})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(setting).Should(gomega.MatchRegexp(`^\$argon2id\$v=19\$m=1024,t=2,p=1\$[+/a-zA-Z0-9]{22}$`))
+
+	setting, err = GenSalt(Params{})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(setting).Should(gomega.HavePrefix("$argon2id$v=19$m=65536,t=1,p=4$"))
+
+	origReader := rand.Reader
+	defer func() { rand.Reader = origReader }()
+	rand.Reader = bytes.NewBuffer([]byte("incomplete"))
+
+	_, err = GenSalt(Params{})
+	g.Expect(err).Should(gomega.Equal(io.ErrUnexpectedEOF))
+}
+
+func TestCrypt(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the reference implementation's vector, starting from its setting
+	h, err := Crypt("password", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(referencePHCHash))
+
+	// crypt(3) style verification with the hash as the setting
+	h, err = Crypt("password", referencePHCHash)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(referencePHCHash))
+
+	h, err = Crypt("bad-password", referencePHCHash)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).ShouldNot(gomega.Equal(referencePHCHash))
+
+	setting, _ := GenSalt(Params{Time: 1, Memory: 1024, Threads: 1})
+	h, err = Crypt("test", setting+"$")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.HavePrefix(setting + "$"))
+	g.Expect(Compare(h, "test")).Should(gomega.Succeed())
+
+	// the hash length is kept
+	short, _ := HashPassword("test", 1, 1024, 1, 16)
+	short, _ = Convert(short, FormatPHC)
+	h, err = Crypt("test", short)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(short))
+
+	// a setting without a hash gives the same result whatever the process defaults are
+	g.Expect(SetDefaults(Params{KeyLen: 16})).Should(gomega.Succeed())
+	defer SetDefaults(Params{})
+	h, err = Crypt("password", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(referencePHCHash))
+}
+
+func TestCryptFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := Crypt("password", "$6$rounds=5000$salt")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidSetting))
+
+	_, err = Crypt("password", testHash)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidSetting))
+
+	_, err = Crypt("password", "$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidArgon2Version))
+
+	_, err = Crypt("password", "$argon2id$v=19$m=65536,t=0,p=1$c29tZXNhbHQ")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidComplexity))
+}
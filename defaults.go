@@ -0,0 +1,251 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// The environment variables read by ConfigFromEnv and LoadDefaults
+const (
+	EnvConfig  = "ARGON2ID_CONFIG"
+	EnvPreset  = "ARGON2ID_PRESET"
+	EnvTime    = "ARGON2ID_TIME"
+	EnvMemory  = "ARGON2ID_MEMORY"
+	EnvThreads = "ARGON2ID_THREADS"
+	EnvKeyLen  = "ARGON2ID_KEYLEN"
+)
+
+// the shortest hash RFC 9106 allows, in bytes
+const minKeyLen = 4
+
+var defaults atomic.Value
+
+// builtinDefaults are the sane defaults used until SetDefaults is called
+func builtinDefaults() Params {
+	return Params{Time: defaultTime, Memory: defaultMemory, Threads: defaultThreads, KeyLen: defaultKeyLen}
+}
+
+func currentDefaults() Params {
+	if p, ok := defaults.Load().(Params); ok {
+		return p
+	}
+
+	return builtinDefaults()
+}
+
+// SetDefaults will replace the parameters used for "0" values, such as by DefaultHashPassword, NeedsRehash and
+// Hasher. A "0" value in p keeps the built-in default, so SetDefaults(Params{}) restores them. An error is returned,
+// and nothing changes, if the parameters could not be hashed with.
+func SetDefaults(p Params) error {
+	p = builtinDefaults().override(p)
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	defaults.Store(p)
+	return nil
+}
+
+// validate will check complete parameters against the limits of Argon2
+func (p Params) validate() error {
+	if p.Memory < 8*uint32(p.Threads) {
+		return fmt.Errorf("synacor/argon2id: memory must be at least 8 KiB per thread, not %d KiB for %d threads", p.Memory, p.Threads)
+	}
+
+	if p.KeyLen < minKeyLen {
+		return fmt.Errorf("synacor/argon2id: key length must be at least %d bytes, not %d", minKeyLen, p.KeyLen)
+	}
+
+	return nil
+}
+
+// config is the content of a configuration file. Preset names a preset that the other values override.
+type config struct {
+	Preset  string `json:"preset"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	KeyLen  uint32 `json:"keylen"`
+}
+
+func (c config) params() (Params, error) {
+	var p Params
+	if c.Preset != "" {
+		var err error
+		if p, err = ParsePreset(c.Preset); err != nil {
+			return Params{}, fmt.Errorf("unknown preset %q", c.Preset)
+		}
+	}
+
+	return p.override(Params{Time: c.Time, Memory: c.Memory, Threads: c.Threads, KeyLen: c.KeyLen}), nil
+}
+
+// set will parse one value, by its key in a configuration file
+func (c *config) set(key, value string) error {
+	var bits int
+	var dst interface{}
+	switch key {
+	case "preset":
+		c.Preset = value
+		return nil
+	case "time":
+		bits, dst = 32, &c.Time
+	case "memory":
+		bits, dst = 32, &c.Memory
+	case "threads":
+		bits, dst = 8, &c.Threads
+	case "keylen":
+		bits, dst = 32, &c.KeyLen
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	n, err := strconv.ParseUint(value, 10, bits)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", key, value)
+	}
+
+	switch dst := dst.(type) {
+	case *uint32:
+		*dst = uint32(n)
+	case *uint8:
+		*dst = uint8(n)
+	}
+
+	return nil
+}
+
+// ReadConfig will read parameters from a JSON file, or from a TOML file if its name ends in ".toml". The keys are
+// "preset", "time", "memory" (in KiB), "threads" and "keylen"; unknown keys are an error. As everywhere, a missing
+// or "0" value means the default. Only the flat "key = value" subset of TOML is understood, with strings quoted.
+func ReadConfig(name string) (Params, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return Params{}, err
+	}
+
+	var c config
+	if strings.EqualFold(filepath.Ext(name), ".toml") {
+		err = parseTOML(data, &c)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	}
+	if err != nil {
+		return Params{}, fmt.Errorf("synacor/argon2id: %s: %v", name, err)
+	}
+
+	p, err := c.params()
+	if err != nil {
+		return Params{}, fmt.Errorf("synacor/argon2id: %s: %v", name, err)
+	}
+
+	return p, nil
+}
+
+// parseTOML will parse the flat "key = value" subset of TOML that a configuration file needs
+func parseTOML(data []byte, c *config) error {
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid string %s", lineNumber, value)
+			}
+			value = unquoted
+		} else if hash := strings.Index(value, "#"); hash >= 0 {
+			value = strings.TrimSpace(value[:hash])
+		}
+
+		if seen[key] {
+			return fmt.Errorf("line %d: %s is set twice", lineNumber, key)
+		}
+		seen[key] = true
+
+		if err := c.set(key, value); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// ConfigFromEnv will read parameters from the ARGON2ID_PRESET, ARGON2ID_TIME, ARGON2ID_MEMORY, ARGON2ID_THREADS and
+// ARGON2ID_KEYLEN environment variables. Unset variables are left "0".
+func ConfigFromEnv() (Params, error) {
+	var c config
+	for key, name := range map[string]string{"preset": EnvPreset, "time": EnvTime, "memory": EnvMemory, "threads": EnvThreads, "keylen": EnvKeyLen} {
+		if value := os.Getenv(name); value != "" {
+			if err := c.set(key, value); err != nil {
+				return Params{}, fmt.Errorf("synacor/argon2id: %s: %v", name, err)
+			}
+		}
+	}
+
+	p, err := c.params()
+	if err != nil {
+		return Params{}, fmt.Errorf("synacor/argon2id: %s: %v", EnvPreset, err)
+	}
+
+	return p, nil
+}
+
+// LoadDefaults will set the defaults from the file named by ARGON2ID_CONFIG, if any, overridden by the other
+// ARGON2ID_* environment variables. It is meant to be called once at startup, so that bad values stop the program
+// before anything is hashed.
+func LoadDefaults() error {
+	var p Params
+	if name := os.Getenv(EnvConfig); name != "" {
+		var err error
+		if p, err = ReadConfig(name); err != nil {
+			return err
+		}
+	}
+
+	env, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	return SetDefaults(p.override(env))
+}
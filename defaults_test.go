@@ -0,0 +1,108 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"os"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestSetDefaults(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetDefaults(Params{})
+
+	g.Expect(SetDefaults(Params{Time: 2, Memory: 1024})).Should(gomega.Succeed())
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: 2, Memory: 1024, Threads: defaultThreads, KeyLen: defaultKeyLen}))
+
+	hashedPassword, err := DefaultHashPassword("my-password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(GetParams(hashedPassword)).Should(gomega.Equal(DefaultParams()))
+	g.Expect(NeedsRehash(hashedPassword, 0, 0, 0, 0)).Should(gomega.BeFalse())
+	g.Expect(NeedsRehash(testHash, 0, 0, 0, 0)).Should(gomega.BeTrue())
+
+	// invalid values leave the defaults alone
+	g.Expect(SetDefaults(Params{Memory: 16, Threads: 4})).Should(gomega.MatchError("synacor/argon2id: memory must be at least 8 KiB per thread, not 16 KiB for 4 threads"))
+	g.Expect(SetDefaults(Params{KeyLen: 2})).Should(gomega.MatchError("synacor/argon2id: key length must be at least 4 bytes, not 2"))
+	g.Expect(DefaultParams().Memory).Should(gomega.Equal(uint32(1024)))
+
+	g.Expect(SetDefaults(Params{})).Should(gomega.Succeed())
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: defaultTime, Memory: defaultMemory, Threads: defaultThreads, KeyLen: defaultKeyLen}))
+}
+
+func TestReadConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(ReadConfig("testdata/config/argon2id.json")).Should(gomega.Equal(Params{Time: 3, Memory: 131072, Threads: 4, KeyLen: 32}))
+	g.Expect(ReadConfig("testdata/config/argon2id.toml")).Should(gomega.Equal(Params{Time: 2, Memory: 19 * 1024, Threads: 2, KeyLen: 24}))
+
+	_, err := ReadConfig("testdata/config/unknown.json")
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: testdata/config/unknown.json: json: unknown field "parallelism"`))
+
+	_, err = ReadConfig("testdata/config/invalid.toml")
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: testdata/config/invalid.toml: line 2: invalid memory "lots"`))
+
+	_, err = ReadConfig("testdata/config/missing.json")
+	g.Expect(os.IsNotExist(err)).Should(gomega.BeTrue())
+}
+
+func setEnv(t *testing.T, env map[string]string) (reset func()) {
+	for name, value := range env {
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return func() {
+		for name := range env {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetDefaults(Params{})
+
+	reset := setEnv(t, map[string]string{EnvConfig: "testdata/config/argon2id.toml", EnvMemory: "32768"})
+	g.Expect(ConfigFromEnv()).Should(gomega.Equal(Params{Memory: 32768}))
+	g.Expect(LoadDefaults()).Should(gomega.Succeed())
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: 2, Memory: 32768, Threads: 2, KeyLen: 24}))
+	reset()
+
+	reset = setEnv(t, map[string]string{EnvPreset: "owasp-5", EnvThreads: "2"})
+	g.Expect(LoadDefaults()).Should(gomega.Succeed())
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: 5, Memory: 7 * 1024, Threads: 2, KeyLen: 32}))
+	reset()
+
+	reset = setEnv(t, map[string]string{EnvThreads: "300"})
+	g.Expect(LoadDefaults()).Should(gomega.MatchError(`synacor/argon2id: ARGON2ID_THREADS: invalid threads "300"`))
+	reset()
+
+	reset = setEnv(t, map[string]string{EnvPreset: "fast"})
+	g.Expect(LoadDefaults()).Should(gomega.MatchError(`synacor/argon2id: ARGON2ID_PRESET: unknown preset "fast"`))
+	reset()
+
+	reset = setEnv(t, map[string]string{EnvMemory: "8", EnvThreads: "4"})
+	g.Expect(LoadDefaults()).ShouldNot(gomega.Succeed())
+	reset()
+
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: 5, Memory: 7 * 1024, Threads: 2, KeyLen: 32}))
+}
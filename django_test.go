@@ -0,0 +1,48 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCompareDjango(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h := "argon2" + referencePHCHash
+	g.Expect(DetectFormat(h)).Should(gomega.Equal(FormatDjango))
+	g.Expect(Compare(h, "password")).Should(gomega.Succeed())
+	g.Expect(Compare(h, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	// Django's argon2i hashes are not argon2id
+	g.Expect(DetectFormat("argon2$argon2i$v=19$m=512,t=2,p=2$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc")).Should(gomega.Equal(FormatUnknown))
+	g.Expect(Compare("argon2$argon2i$v=19$m=512,t=2,p=2$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(Compare("ARGON2"+referencePHCHash, "password")).Should(gomega.Equal(ErrInvalidHash))
+
+	converted, err := Convert(h, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+	hashedPassword, err := Hasher{Params: Params{Memory: 1024}, Format: FormatDjango}.Hash("my-password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(hashedPassword).Should(gomega.HavePrefix("argon2$argon2id$v=19$m=1024,t=1,p=4$"))
+	g.Expect(Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+}
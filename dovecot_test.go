@@ -0,0 +1,68 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCompareDovecot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h := "{ARGON2ID}" + referencePHCHash
+	g.Expect(DetectFormat(h)).Should(gomega.Equal(FormatDovecot))
+	g.Expect(Compare(h, "password")).Should(gomega.Succeed())
+	g.Expect(Compare(h, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	// scheme names are case insensitive
+	g.Expect(Compare("{argon2id}"+referencePHCHash, "password")).Should(gomega.Succeed())
+
+	// and the case a hash was written with is kept
+	converted, err := Convert("{argon2id}"+referencePHCHash, FormatDovecot)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal("{argon2id}" + referencePHCHash))
+
+	data, err := Hash("{argon2id}" + referencePHCHash).MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	var back Hash
+	g.Expect(back.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(back).Should(gomega.Equal(Hash("{argon2id}" + referencePHCHash)))
+
+	converted, err = Convert("{argon2id}"+referencePHCHash, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+	// the OpenLDAP scheme is a prefix of this one, but they must not be confused
+	g.Expect(DetectFormat("{ARGON2}" + referencePHCHash)).Should(gomega.Equal(FormatLDAP))
+
+	g.Expect(Compare("{ARGON2ID}"+testHash, "my-password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(Compare("{ARGON2ID}", "password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(DetectFormat("{ARGON2ID}bad-hash")).Should(gomega.Equal(FormatUnknown))
+
+	converted, err = Convert(h, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+	hashedPassword, err := Hasher{Params: Params{Memory: 1024}, Format: FormatDovecot}.Hash("my-password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(hashedPassword).Should(gomega.HavePrefix("{ARGON2ID}$argon2id$v=19$m=1024,t=1,p=4$"))
+	g.Expect(Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+}
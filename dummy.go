@@ -0,0 +1,113 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// the most decoys kept at once; parameters are normally fixed by configuration, so this is only reached if they are
+// not
+const maxDecoys = 16
+
+var (
+	decoysMu sync.Mutex
+	decoys   = make(map[Params]*hashed)
+)
+
+// dummyPassword is compared against the decoys. Argon2 takes the same time for any password of a similar length.
+var dummyPassword = []byte("synacor/argon2id: dummy password")
+
+// DummyCompare will spend the same time and memory as comparing a password with a hash made with p, and then return
+// ErrMismatchedHashAndPassword. Use it when there is no hash to compare with, such as for a user that does not exist,
+// so that the time taken does not tell whether the user exists. "0" values in p are replaced by their sane defaults.
+// It is reported to the Observer as a mismatched OperationCompare.
+func DummyCompare(p Params) error {
+	start := now()
+	p = p.withDefaults()
+	err := dummyCompare(p)
+	observe(OperationCompare, start, p, FormatUnknown, err)
+	return err
+}
+
+func dummyCompare(p Params) error {
+	h, err := decoy(p)
+	if err != nil {
+		return err
+	}
+
+	h.matches(dummyPassword)
+	return ErrMismatchedHashAndPassword
+}
+
+// CompareOrDummy is Compare, except that when hashedPassword cannot be parsed, for example because it is "" for a user
+// that does not exist, a dummy comparison with the defaults (see SetDefaults) is made before the error is returned.
+// If the defaults are the parameters the stored hashes were made with, every call takes about as long.
+func CompareOrDummy(hashedPassword, password string) error {
+	pw := []byte(password)
+	defer wipe(pw)
+	return CompareOrDummyBytes(hashedPassword, pw)
+}
+
+// CompareOrDummyBytes is CompareOrDummy for a password held in a byte slice, which is not copied. The caller may wipe
+// it afterwards.
+func CompareOrDummyBytes(hashedPassword string, password []byte) error {
+	start := now()
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		dummyCompare(currentDefaults())
+		observe(OperationCompare, start, Params{}, DetectFormat(hashedPassword), err)
+		return err
+	}
+
+	defer h.wipe()
+	err = h.compare(password)
+	observe(OperationCompare, start, h.params(), h.format, err)
+	return err
+}
+
+// decoy will return a hash with the parameters p and random contents, creating it the first time. It is never wiped
+// because nothing matches it.
+func decoy(p Params) (*hashed, error) {
+	decoysMu.Lock()
+	defer decoysMu.Unlock()
+
+	if h, ok := decoys[p]; ok {
+		return h, nil
+	}
+
+	salt, err := generateSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	h := newHashed(FormatSynacor, p, salt)
+	h.hash = make([]byte, p.KeyLen)
+	if _, err := io.ReadFull(rand.Reader, h.hash); err != nil {
+		return nil, err
+	}
+
+	if len(decoys) >= maxDecoys {
+		decoys = make(map[Params]*hashed)
+	}
+	decoys[p] = h
+	return h, nil
+}
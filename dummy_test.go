@@ -0,0 +1,95 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// begunObserver records the parameters of every Argon2 computation
+type begunObserver struct {
+	recordingObserver
+	begun []Params
+}
+
+func (o *begunObserver) Begin(p Params) {
+	o.begun = append(o.begun, p)
+}
+
+func (o *begunObserver) End(Params) {}
+
+func TestDummyCompare(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	o := &begunObserver{}
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	p := Params{Time: 2, Memory: 1024, Threads: 2, KeyLen: 16}
+	g.Expect(DummyCompare(p)).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(DummyCompare(p)).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(o.begun).Should(gomega.Equal([]Params{p, p}))
+	g.Expect(o.events).Should(gomega.HaveLen(2))
+	g.Expect(o.events[0].Operation).Should(gomega.Equal(OperationCompare))
+	g.Expect(o.events[0].Params).Should(gomega.Equal(p))
+	g.Expect(o.events[0].Outcome).Should(gomega.Equal(OutcomeMismatch))
+
+	// the decoy is made once per set of parameters, with the lengths of a real hash
+	h, err := decoy(p)
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+	g.Expect(decoy(p)).Should(gomega.BeIdenticalTo(h))
+	g.Expect(h.salt).Should(gomega.HaveLen(saltLen))
+	g.Expect(h.hash).Should(gomega.HaveLen(16))
+	g.Expect(h.hash).ShouldNot(gomega.Equal(make([]byte, 16)))
+
+	o.begun = nil
+	g.Expect(DummyCompare(Params{Memory: 1024})).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(o.begun).Should(gomega.Equal([]Params{{Time: defaultTime, Memory: 1024, Threads: defaultThreads, KeyLen: defaultKeyLen}}))
+}
+
+func TestCompareOrDummy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	p := Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16}
+	g.Expect(SetDefaults(p)).Should(gomega.Succeed())
+	defer SetDefaults(Params{})
+
+	hashedPassword, err := DefaultHashPassword("password")
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+	o := &begunObserver{}
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	g.Expect(CompareOrDummy(hashedPassword, "password")).Should(gomega.Succeed())
+	g.Expect(CompareOrDummy(hashedPassword, "wrong")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(o.begun).Should(gomega.Equal([]Params{p, p}))
+
+	// a missing or unparsable hash costs the same Argon2 computation, but reports why it failed
+	o.begun = nil
+	g.Expect(CompareOrDummy("", "password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(CompareOrDummyBytes("$argon2id19$1,1024,1$bad", []byte("password"))).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(o.begun).Should(gomega.Equal([]Params{p, p}))
+
+	g.Expect(o.events).Should(gomega.HaveLen(4))
+	g.Expect(o.events[2].Outcome).Should(gomega.Equal(OutcomeInvalidHash))
+	g.Expect(o.events[3].Format).Should(gomega.Equal(FormatUnknown))
+}
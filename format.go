@@ -0,0 +1,215 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAmbiguousEncoding is an error when a hash cannot be converted because its salt and hash are valid in both base64
+// alphabets, so its bytes are only known once a password has been compared against it
+var ErrAmbiguousEncoding = errors.New("synacor/argon2id: the hashed password could use either base64 alphabet")
+
+// Format identifies how a hash is serialized to a string
+type Format int
+
+// The known formats
+const (
+	FormatUnknown Format = iota
+
+	// FormatSynacor is this library's "$argon2id19$time,memory,threads$salt$hash" format
+	FormatSynacor
+
+	// FormatSynacorStd is FormatSynacor with the standard base64 alphabet, as written by earlier releases. Compare still
+	// accepts it, but NeedsRehash reports that it should be replaced.
+	FormatSynacorStd
+
+	// FormatPHC is the PHC string format used by the reference implementation and most other libraries:
+	// "$argon2id$v=19$m=memory,t=time,p=threads$salt$hash" with unpadded standard base64
+	FormatPHC
+
+	// FormatLDAP is FormatPHC with the "{ARGON2}" userPassword scheme prefix of OpenLDAP's pw-argon2 module
+	FormatLDAP
+
+	// FormatDovecot is FormatPHC with the "{ARGON2ID}" password scheme prefix of Dovecot
+	FormatDovecot
+
+	// FormatDjango is FormatPHC with the "argon2" algorithm prefix of Django's Argon2PasswordHasher
+	FormatDjango
+
+	// FormatSpring is FormatPHC with the "{argon2}" encoder id of Spring Security's DelegatingPasswordEncoder. Hashes
+	// written by Argon2PasswordEncoder itself, without an id, are FormatPHC.
+	FormatSpring
+
+	// FormatSpringV58 is FormatSpring with the "{argon2@SpringSecurity_v5_8}" encoder id that Spring Security 5.8 and
+	// later use for their default parameters
+	FormatSpringV58
+)
+
+// codec reads and writes one format. match is a cheap check of the prefix that decides which codec decodes a string.
+// detect looks at the shape of the whole string and may return a sibling format, such as FormatSynacorStd for
+// FormatSynacor. Formats that are decoded by a sibling have no match, detect or decode.
+type codec struct {
+	name   string
+	legacy bool
+	match  func(string) bool
+	detect func(string) Format
+	decode func(string) (*hashed, error)
+	encode func(*hashed) string
+}
+
+// codecs are tried in order by DetectFormat and Compare. FormatSpring comes before FormatLDAP because its case
+// sensitive "{argon2}" would otherwise be read as OpenLDAP's case insensitive "{ARGON2}".
+var codecs = []Format{FormatSynacor, FormatSynacorStd, FormatPHC, FormatDjango, FormatSpring, FormatSpringV58, FormatLDAP, FormatDovecot}
+
+var codecsByFormat = map[Format]codec{
+	FormatSynacor: {
+		name:   "synacor",
+		match:  matchSynacor,
+		detect: detectSynacor,
+		decode: newHashedFromSynacor,
+		encode: encodeSynacor,
+	},
+	FormatSynacorStd: {
+		name:   "synacor-std",
+		legacy: true,
+		encode: encodeSynacor,
+	},
+	FormatPHC: {
+		name:   "phc",
+		match:  matchPHC,
+		detect: detectPHC,
+		decode: newHashedFromPHC,
+		encode: encodePHC,
+	},
+	FormatLDAP: {
+		name:   "ldap",
+		match:  ldapScheme.match,
+		detect: ldapScheme.detect,
+		decode: ldapScheme.decode,
+		encode: ldapScheme.encode,
+	},
+	FormatDovecot: {
+		name:   "dovecot",
+		match:  dovecotScheme.match,
+		detect: dovecotScheme.detect,
+		decode: dovecotScheme.decode,
+		encode: dovecotScheme.encode,
+	},
+	FormatDjango: {
+		name:   "django",
+		match:  djangoScheme.match,
+		detect: djangoScheme.detect,
+		decode: djangoScheme.decode,
+		encode: djangoScheme.encode,
+	},
+	FormatSpring: {
+		name:   "spring",
+		match:  springScheme.match,
+		detect: springScheme.detect,
+		decode: springScheme.decode,
+		encode: springScheme.encode,
+	},
+	FormatSpringV58: {
+		name:   "spring-v5.8",
+		match:  springV58Scheme.match,
+		detect: springV58Scheme.detect,
+		decode: springV58Scheme.decode,
+		encode: springV58Scheme.encode,
+	},
+}
+
+func (f Format) String() string {
+	if c, ok := codecsByFormat[f]; ok {
+		return c.name
+	}
+
+	return "unknown"
+}
+
+// Formats will return every format that can be read and written
+func Formats() []Format {
+	return append([]Format(nil), codecs...)
+}
+
+// ParseFormat will return the Format with the given name, as returned by Format.String()
+func ParseFormat(name string) (Format, error) {
+	for _, f := range codecs {
+		if codecsByFormat[f].name == name {
+			return f, nil
+		}
+	}
+
+	return FormatUnknown, fmt.Errorf("synacor/argon2id: unknown format %q", name)
+}
+
+// DetectFormat will return the format of hashedPassword, or FormatUnknown if it is not recognised. It only looks at
+// the shape of the string; Compare may still reject it.
+func DetectFormat(hashedPassword string) Format {
+	for _, f := range codecs {
+		if detect := codecsByFormat[f].detect; detect != nil {
+			if format := detect(hashedPassword); format != FormatUnknown {
+				return format
+			}
+		}
+	}
+
+	return FormatUnknown
+}
+
+// Convert will rewrite hashedPassword in the target format. No password is needed because every format holds the
+// same parameters, salt and hash. The hash is validated in the same way as Compare validates it. Converting to the
+// format the hash is already in keeps its scheme prefix as it was written, such as "{argon2id}" for FormatDovecot.
+func Convert(hashedPassword string, target Format) (string, error) {
+	c, ok := codecsByFormat[target]
+	if !ok {
+		return "", fmt.Errorf("synacor/argon2id: cannot convert to format %v", target)
+	}
+
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		return "", err
+	}
+
+	if h.legacyAlternative() != nil && target != h.format {
+		return "", ErrAmbiguousEncoding
+	}
+
+	if target != h.format {
+		h.format, h.prefix = target, ""
+	}
+	return c.encode(h), nil
+}
+
+func newHashedFromHashedPassword(hashedPassword string) (*hashed, error) {
+	for _, f := range codecs {
+		c := codecsByFormat[f]
+		if c.match != nil && c.match(hashedPassword) {
+			return c.decode(hashedPassword)
+		}
+	}
+
+	return nil, ErrInvalidHash
+}
+
+// encode will serialize the hash in its format
+func (h *hashed) encode() string {
+	return codecsByFormat[h.format].encode(h)
+}
@@ -0,0 +1,82 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestDetectFormat(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, _ := HashPassword("test", 1, 1024, 1, 16)
+	g.Expect(DetectFormat(h)).Should(gomega.Equal(FormatSynacor))
+	g.Expect(DetectFormat("bad-hash")).Should(gomega.Equal(FormatUnknown))
+	g.Expect(FormatSynacor.String()).Should(gomega.Equal("synacor"))
+}
+
+func TestParseFormat(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(Formats()).Should(gomega.Equal([]Format{FormatSynacor, FormatSynacorStd, FormatPHC, FormatDjango, FormatSpring, FormatSpringV58, FormatLDAP, FormatDovecot}))
+	for _, f := range Formats() {
+		parsed, err := ParseFormat(f.String())
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(parsed).Should(gomega.Equal(f))
+	}
+
+	_, err := ParseFormat("bogus")
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: unknown format "bogus"`))
+}
+
+func TestConvert(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	phc, err := Convert(testHash, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(phc).Should(gomega.Equal("$argon2id$v=19$m=65536,t=1,p=4$hlUKU+yNQGbTHB/egD5EvA$aEfk+AWSuGIHDuCyXIJBW7nynxJYNdWn9O1UZyHL9Q0"))
+	g.Expect(DetectFormat(phc)).Should(gomega.Equal(FormatPHC))
+	g.Expect(Compare(phc, "my-password")).Should(gomega.Succeed())
+
+	back, err := Convert(phc, FormatSynacor)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(back).Should(gomega.Equal(testHash))
+
+	synacor, err := Convert(referencePHCHash, FormatSynacor)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(Compare(synacor, "password")).Should(gomega.Succeed())
+
+	// legacy hashes can be moved to the current alphabet without the password
+	current, err := Convert(legacyHashWithPlus, FormatSynacor)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(Compare(current, "legacy-1")).Should(gomega.Succeed())
+	needsRehash, _ := NeedsRehash(current, 1, 1024, 1, 32)
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	_, err = Convert(legacyHashAmbiguous, FormatPHC)
+	g.Expect(err).Should(gomega.Equal(ErrAmbiguousEncoding))
+
+	_, err = Convert("bad-hash", FormatPHC)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+
+	_, err = Convert(testHash, FormatUnknown)
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: cannot convert to format unknown"))
+}
@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/onsi/gomega v1.4.3
 	golang.org/x/crypto v0.9.0
+	golang.org/x/sys v0.8.0
 )
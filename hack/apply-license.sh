@@ -37,7 +37,8 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 EOF
 )
 
-for file in $(find . \! -path './.git/*' -type f -name '*.go' | xargs ack -L 'GNU General Public License'); do 
+# internal/argon2 is a copy of BSD-licensed code from golang.org/x/crypto and keeps its own license
+for file in $(find . \! -path './.git/*' \! -path './internal/argon2/*' -type f -name '*.go' | xargs ack -L 'GNU General Public License'); do 
     echo "updating $file..."
     tmpfile=$(mktemp) 
     ( echo "$LICENSE" ; echo ""; cat $file ) > $tmpfile
@@ -0,0 +1,145 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Hash is a hashed password that has been fully validated, so that bad data is caught when it is loaded rather than
+// on the next Compare. It can be scanned from and stored in a database, and used in JSON and text encodings. The
+// empty Hash stands for no hash, and is stored as NULL and encoded as JSON null.
+type Hash string
+
+// ParseHash will validate hashedPassword and return it as a Hash. The checks are the same as those made by Compare
+// before it hashes the password.
+func ParseHash(hashedPassword string) (Hash, error) {
+	if _, err := newHashedFromHashedPassword(hashedPassword); err != nil {
+		return "", err
+	}
+
+	return Hash(hashedPassword), nil
+}
+
+// String will return the hashed password
+func (h Hash) String() string {
+	return string(h)
+}
+
+// Compare will compare the hash with the supplied password. See Compare.
+func (h Hash) Compare(password string) error {
+	return Compare(string(h), password)
+}
+
+// Params will return the parameters that were used to create the hash
+func (h Hash) Params() (Params, error) {
+	return GetParams(string(h))
+}
+
+// Scan implements sql.Scanner. A NULL value results in the empty Hash.
+func (h *Hash) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*h = ""
+		return nil
+	case string:
+		return h.set(src)
+	case []byte:
+		return h.set(string(src))
+	}
+
+	return fmt.Errorf("synacor/argon2id: cannot scan %T into a Hash", src)
+}
+
+// Value implements driver.Valuer. The empty Hash is stored as NULL.
+func (h Hash) Value() (driver.Value, error) {
+	if h == "" {
+		return nil, nil
+	}
+
+	if _, err := ParseHash(string(h)); err != nil {
+		return nil, err
+	}
+
+	return string(h), nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (h Hash) MarshalText() ([]byte, error) {
+	if h == "" {
+		return []byte{}, nil
+	}
+
+	if _, err := ParseHash(string(h)); err != nil {
+		return nil, err
+	}
+
+	return []byte(h), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (h *Hash) UnmarshalText(text []byte) error {
+	return h.set(string(text))
+}
+
+// MarshalJSON implements json.Marshaler. The empty Hash is encoded as null.
+func (h Hash) MarshalJSON() ([]byte, error) {
+	if h == "" {
+		return []byte("null"), nil
+	}
+
+	text, err := h.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. null results in the empty Hash.
+func (h *Hash) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*h = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return h.set(s)
+}
+
+func (h *Hash) set(s string) error {
+	if s == "" {
+		*h = ""
+		return nil
+	}
+
+	parsed, err := ParseHash(s)
+	if err != nil {
+		return err
+	}
+
+	*h = parsed
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	stdencoding "encoding"
+	"encoding/json"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+var (
+	_ sql.Scanner                 = (*Hash)(nil)
+	_ driver.Valuer               = Hash("")
+	_ stdencoding.TextMarshaler   = Hash("")
+	_ stdencoding.TextUnmarshaler = (*Hash)(nil)
+	_ json.Marshaler              = Hash("")
+	_ json.Unmarshaler            = (*Hash)(nil)
+)
+
+const testHash = "$argon2id19$1,65536,4$fjSIS8wLOEZRF/9ceB3Ct.$YCdi8.UQsEGFBsAwVGH/U5lwlvHWLbUl7MzSXwFJ7Oy"
+
+func TestParseHash(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, err := ParseHash(testHash)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(h.String()).Should(gomega.Equal(testHash))
+	g.Expect(h.Compare("my-password")).Should(gomega.Succeed())
+
+	p, err := h.Params()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(p).Should(gomega.Equal(Params{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32}))
+
+	_, err = ParseHash("bad-hash")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+
+	_, err = ParseHash("$argon2id19$0,65536,4$Oy7MSyjRTCTMTAzROSvSGO$QV5KGjrKZO6C.8St0T7HCTL0AvuCxgf5O.Okwj90a3a")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidComplexity))
+}
+
+func TestHashSQL(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var h Hash
+	g.Expect(h.Scan(testHash)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash(testHash)))
+
+	g.Expect(h.Scan([]byte(testHash))).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash(testHash)))
+
+	v, err := h.Value()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(v).Should(gomega.Equal(testHash))
+
+	g.Expect(h.Scan(nil)).Should(gomega.Succeed())
+	g.Expect(h).Should(gomega.Equal(Hash("")))
+
+	v, err = h.Value()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(v).Should(gomega.BeNil())
+
+	g.Expect(h.Scan("bad-hash")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(h.Scan(42)).Should(gomega.MatchError("synacor/argon2id: cannot scan int into a Hash"))
+
+	_, err = Hash("bad-hash").Value()
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+}
+
+func TestHashJSON(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type user struct {
+		Password Hash `json:"password"`
+	}
+
+	data, err := json.Marshal(user{Password: testHash})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(data)).Should(gomega.Equal(`{"password":"` + testHash + `"}`))
+
+	var u user
+	g.Expect(json.Unmarshal(data, &u)).Should(gomega.Succeed())
+	g.Expect(u.Password).Should(gomega.Equal(Hash(testHash)))
+
+	data, err = json.Marshal(user{})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(data)).Should(gomega.Equal(`{"password":null}`))
+
+	g.Expect(json.Unmarshal([]byte(`{"password":null}`), &u)).Should(gomega.Succeed())
+	g.Expect(u.Password).Should(gomega.Equal(Hash("")))
+
+	g.Expect(json.Unmarshal([]byte(`{"password":"bad-hash"}`), &u)).Should(gomega.MatchError(ErrInvalidHash))
+	g.Expect(json.Unmarshal([]byte(`{"password":42}`), &u)).ShouldNot(gomega.Succeed())
+
+	_, err = json.Marshal(user{Password: "bad-hash"})
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(ErrInvalidHash.Error())))
+}
+
+func TestHashText(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var h Hash
+	g.Expect(h.UnmarshalText([]byte(testHash))).Should(gomega.Succeed())
+	text, err := h.MarshalText()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(text)).Should(gomega.Equal(testHash))
+
+	g.Expect(h.UnmarshalText([]byte("bad-hash"))).Should(gomega.Equal(ErrInvalidHash))
+
+	text, err = Hash("").MarshalText()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(text).Should(gomega.BeEmpty())
+}
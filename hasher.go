@@ -0,0 +1,66 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import "fmt"
+
+// Hasher hashes passwords with fixed parameters into a chosen format. The zero value hashes like DefaultHashPassword.
+type Hasher struct {
+	// Params are the parameters to hash with. "0" values are replaced by their sane defaults.
+	Params Params
+
+	// Format is the format of the hashes. If FormatUnknown, FormatSynacor is used.
+	Format Format
+
+	// Progress, if not nil, is called as each hash is computed
+	Progress ProgressFunc
+}
+
+// Hash will hash the password with a new random salt
+func (hr Hasher) Hash(password string) (string, error) {
+	pw := []byte(password)
+	defer wipe(pw)
+	return hr.HashBytes(pw)
+}
+
+// HashBytes is Hash for a password held in a byte slice, which is not copied. The caller may wipe it afterwards.
+func (hr Hasher) HashBytes(password []byte) (hashedPassword string, err error) {
+	p := hr.Params.withDefaults()
+	format := hr.Format
+	if format == FormatUnknown {
+		format = FormatSynacor
+	}
+
+	if _, ok := codecsByFormat[format]; !ok {
+		return "", fmt.Errorf("synacor/argon2id: cannot hash in format %v", format)
+	}
+
+	start := now()
+	defer func() { observe(OperationHash, start, p, format, err) }()
+
+	salt, err := generateSalt()
+	if err != nil {
+		return "", err
+	}
+
+	h := newHashed(format, p, salt)
+	h.hash = deriveKey(password, salt, p, hr.Progress)
+	defer h.wipe()
+	return h.encode(), nil
+}
@@ -0,0 +1,47 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestHasher(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	p := Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16}
+	for _, f := range Formats() {
+		h, err := Hasher{Params: p, Format: f}.Hash("test")
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(DetectFormat(h)).Should(gomega.Equal(f), f.String())
+		g.Expect(Compare(h, "test")).Should(gomega.Succeed(), f.String())
+
+		hp, _ := GetParams(h)
+		g.Expect(hp).Should(gomega.Equal(p))
+	}
+
+	h, err := Hasher{Params: p}.Hash("test")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(DetectFormat(h)).Should(gomega.Equal(FormatSynacor))
+
+	_, err = Hasher{Format: Format(99)}.Hash("test")
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: cannot hash in format unknown"))
+}
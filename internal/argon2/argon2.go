@@ -0,0 +1,293 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package argon2 is a copy of golang.org/x/crypto/argon2 (v0.9.0) that gives the synacor/argon2id package control
+// over how the computation is carried out, such as wiping the working memory before it is released. The output is
+// bit-identical to golang.org/x/crypto/argon2.IDKey. blamka_amd64.s also has the argument name and frame pointer fixes
+// that were made upstream after v0.9.0.
+package argon2
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// The Argon2 version implemented by this package.
+const Version = 0x13
+
+const (
+	argon2d = iota
+	argon2i
+	argon2id
+)
+
+// Config controls how a key is derived. None of the options change the derived key.
+type Config struct {
+	// Wipe zeroes the working memory, and the other intermediate values that depend on the password, before IDKey
+	// returns
+	Wipe bool
+
+	// Pool, if not nil, provides the working memory and takes it back afterwards. Memory returned to a Pool is always
+	// zeroed.
+	Pool *Pool
+
+	// Workers, if not nil, computes the lanes instead of a new goroutine for each lane and slice
+	Workers *Workers
+
+	// Progress, if not nil, is called on the calling goroutine after each slice of each pass over the memory, with
+	// the pass and slice counted from 0
+	Progress func(pass, slice uint32)
+}
+
+// IDKey derives a key from the password, salt, and cost parameters using Argon2id, in the same way as
+// golang.org/x/crypto/argon2.IDKey.
+func IDKey(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32, c Config) []byte {
+	return deriveKey(argon2id, password, salt, nil, nil, time, memory, threads, keyLen, c)
+}
+
+// newBlocks allocates zeroed working memory, which pass 0 relies on. It is a variable so that tests can inspect the
+// memory after use.
+var newBlocks = func(memory uint32) []block {
+	return make([]block, memory)
+}
+
+func deriveKey(mode int, password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32, c Config) []byte {
+	if time < 1 {
+		panic("argon2: number of rounds too small")
+	}
+	if threads < 1 {
+		panic("argon2: parallelism degree too low")
+	}
+	h0 := initHash(password, salt, secret, data, time, memory, uint32(threads), keyLen, mode)
+
+	memory = memory / (syncPoints * uint32(threads)) * (syncPoints * uint32(threads))
+	if memory < 2*syncPoints*uint32(threads) {
+		memory = 2 * syncPoints * uint32(threads)
+	}
+	var B []block
+	if c.Pool != nil {
+		B = c.Pool.get(memory)
+		defer c.Pool.put(B)
+	} else {
+		B = newBlocks(memory)
+	}
+	initBlocks(B, &h0, memory, uint32(threads), c.Wipe)
+	processBlocks(B, time, memory, uint32(threads), mode, c.Workers, c.Progress)
+	key := extractKey(B, memory, uint32(threads), keyLen, c.Wipe)
+
+	if c.Wipe {
+		wipeBytes(h0[:])
+		if c.Pool == nil {
+			wipeBlocks(B)
+		}
+	}
+	return key
+}
+
+// wipeBlocks zeroes B. The KeepAlive stops the stores from being treated as dead.
+func wipeBlocks(B []block) {
+	for i := range B {
+		B[i] = block{}
+	}
+	runtime.KeepAlive(B)
+}
+
+// wipeBytes zeroes b. It is not inlined so that zeroing a local array is not dropped as a dead store.
+//
+//go:noinline
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+const (
+	blockLength = 128
+	syncPoints  = 4
+)
+
+// SlicesPerPass is the number of slices each pass over the memory is made in
+const SlicesPerPass = syncPoints
+
+type block [blockLength]uint64
+
+func initHash(password, salt, key, data []byte, time, memory, threads, keyLen uint32, mode int) [blake2b.Size + 8]byte {
+	var (
+		h0     [blake2b.Size + 8]byte
+		params [24]byte
+		tmp    [4]byte
+	)
+
+	b2, _ := blake2b.New512(nil)
+	binary.LittleEndian.PutUint32(params[0:4], threads)
+	binary.LittleEndian.PutUint32(params[4:8], keyLen)
+	binary.LittleEndian.PutUint32(params[8:12], memory)
+	binary.LittleEndian.PutUint32(params[12:16], time)
+	binary.LittleEndian.PutUint32(params[16:20], uint32(Version))
+	binary.LittleEndian.PutUint32(params[20:24], uint32(mode))
+	b2.Write(params[:])
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(password)))
+	b2.Write(tmp[:])
+	b2.Write(password)
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(salt)))
+	b2.Write(tmp[:])
+	b2.Write(salt)
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(key)))
+	b2.Write(tmp[:])
+	b2.Write(key)
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(data)))
+	b2.Write(tmp[:])
+	b2.Write(data)
+	b2.Sum(h0[:0])
+	return h0
+}
+
+func initBlocks(B []block, h0 *[blake2b.Size + 8]byte, memory, threads uint32, wipe bool) {
+	var block0 [1024]byte
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+
+		binary.LittleEndian.PutUint32(h0[blake2b.Size:], 0)
+		blake2bHash(block0[:], h0[:])
+		for i := range B[j+0] {
+			B[j+0][i] = binary.LittleEndian.Uint64(block0[i*8:])
+		}
+
+		binary.LittleEndian.PutUint32(h0[blake2b.Size:], 1)
+		blake2bHash(block0[:], h0[:])
+		for i := range B[j+1] {
+			B[j+1][i] = binary.LittleEndian.Uint64(block0[i*8:])
+		}
+	}
+
+	if wipe {
+		wipeBytes(block0[:])
+	}
+}
+
+func processBlocks(B []block, time, memory, threads uint32, mode int, workers *Workers, progress func(pass, slice uint32)) {
+	lanes := memory / threads
+	segments := lanes / syncPoints
+
+	processSegment := func(n, slice, lane uint32, wg *sync.WaitGroup) {
+		var addresses, in, zero block
+		if mode == argon2i || (mode == argon2id && n == 0 && slice < syncPoints/2) {
+			in[0] = uint64(n)
+			in[1] = uint64(lane)
+			in[2] = uint64(slice)
+			in[3] = uint64(memory)
+			in[4] = uint64(time)
+			in[5] = uint64(mode)
+		}
+
+		index := uint32(0)
+		if n == 0 && slice == 0 {
+			index = 2 // we have already generated the first two blocks
+			if mode == argon2i || mode == argon2id {
+				in[6]++
+				processBlock(&addresses, &in, &zero)
+				processBlock(&addresses, &addresses, &zero)
+			}
+		}
+
+		offset := lane*lanes + slice*segments + index
+		var random uint64
+		for index < segments {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += lanes // last block in lane
+			}
+			if mode == argon2i || (mode == argon2id && n == 0 && slice < syncPoints/2) {
+				if index%blockLength == 0 {
+					in[6]++
+					processBlock(&addresses, &in, &zero)
+					processBlock(&addresses, &addresses, &zero)
+				}
+				random = addresses[index%blockLength]
+			} else {
+				random = B[prev][0]
+			}
+			newOffset := indexAlpha(random, lanes, segments, threads, n, slice, lane, index)
+			processBlockXOR(&B[offset], &B[prev], &B[newOffset])
+			index, offset = index+1, offset+1
+		}
+		wg.Done()
+	}
+
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < syncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < threads; lane++ {
+				wg.Add(1)
+				if workers == nil {
+					go processSegment(n, slice, lane, &wg)
+					continue
+				}
+
+				// the segments of a slice are independent, so they can be computed in any order
+				n, slice, lane := n, slice, lane
+				workers.run(func() { processSegment(n, slice, lane, &wg) })
+			}
+			wg.Wait()
+
+			if progress != nil {
+				progress(n, slice)
+			}
+		}
+	}
+
+}
+
+func extractKey(B []block, memory, threads, keyLen uint32, wipe bool) []byte {
+	lanes := memory / threads
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range B[(lane*lanes)+lanes-1] {
+			B[memory-1][i] ^= v
+		}
+	}
+
+	var block [1024]byte
+	for i, v := range B[memory-1] {
+		binary.LittleEndian.PutUint64(block[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	blake2bHash(key, block[:])
+
+	if wipe {
+		wipeBytes(block[:])
+	}
+	return key
+}
+
+func indexAlpha(rand uint64, lanes, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(rand>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+	m, s := 3*segments, ((slice+1)%syncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+	return phi(rand, uint64(m), uint64(s), refLane, lanes)
+}
+
+func phi(rand, m, s uint64, lane, lanes uint32) uint32 {
+	p := rand & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * m) >> 32
+	return lane*lanes + uint32((s+m-(p+1))%uint64(lanes))
+}
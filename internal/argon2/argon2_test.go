@@ -0,0 +1,109 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/argon2"
+)
+
+// the Argon2id test vector from RFC 9106 section 5.3
+var (
+	rfcPassword = bytes.Repeat([]byte{0x01}, 32)
+	rfcSalt     = bytes.Repeat([]byte{0x02}, 16)
+	rfcSecret   = bytes.Repeat([]byte{0x03}, 8)
+	rfcData     = bytes.Repeat([]byte{0x04}, 12)
+	rfcTag      = []byte{
+		0x0d, 0x64, 0x0d, 0xf5, 0x8d, 0x78, 0x76, 0x6c,
+		0x08, 0xc0, 0x37, 0xa3, 0x4a, 0x8b, 0x53, 0xc9,
+		0xd0, 0x1e, 0xf0, 0x45, 0x2d, 0x75, 0xb6, 0x5e,
+		0xb5, 0x25, 0x20, 0xe9, 0x6b, 0x01, 0xe6, 0x59,
+	}
+)
+
+func TestDeriveKeyRFC9106(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer func(sse4 bool) { useSSE4 = sse4 }(useSSE4)
+
+	for _, sse4 := range []bool{useSSE4, false} {
+		useSSE4 = sse4
+		for _, c := range []Config{{}, {Wipe: true}} {
+			tag := deriveKey(argon2id, rfcPassword, rfcSalt, rfcSecret, rfcData, 3, 32, 4, 32, c)
+			g.Expect(tag).Should(gomega.Equal(rfcTag))
+		}
+	}
+}
+
+func TestIDKeyMatchesXCrypto(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		time, memory uint32
+		threads      uint8
+		keyLen       uint32
+	}{
+		{1, 64, 1, 32},
+		{2, 64, 2, 24},
+		{3, 256, 3, 16},
+		{1, 1024, 4, 64},
+		{4, 1000, 8, 100},
+		{1, 8, 1, 4},
+	}
+
+	for _, test := range tests {
+		for _, c := range []Config{{}, {Wipe: true}} {
+			want := argon2.IDKey([]byte("password"), []byte("somesalt"), test.time, test.memory, test.threads, test.keyLen)
+			got := IDKey([]byte("password"), []byte("somesalt"), test.time, test.memory, test.threads, test.keyLen, c)
+			g.Expect(got).Should(gomega.Equal(want), "%+v %+v", test, c)
+		}
+	}
+}
+
+func TestIDKeyWipe(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer func(f func(uint32) []block) { newBlocks = f }(newBlocks)
+
+	var B []block
+	newBlocks = func(memory uint32) []block {
+		B = make([]block, memory)
+		return B
+	}
+
+	IDKey([]byte("password"), []byte("somesalt"), 1, 64, 2, 32, Config{})
+	g.Expect(B).Should(gomega.ContainElement(gomega.Not(gomega.Equal(block{}))))
+
+	IDKey([]byte("password"), []byte("somesalt"), 1, 64, 2, 32, Config{Wipe: true})
+	g.Expect(B).Should(gomega.HaveLen(64))
+	for i := range B {
+		g.Expect(B[i]).Should(gomega.Equal(block{}), "block %d", i)
+	}
+}
+
+func TestIDKeyProgress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var calls [][2]uint32
+	c := Config{Progress: func(pass, slice uint32) { calls = append(calls, [2]uint32{pass, slice}) }}
+	key := IDKey([]byte("password"), []byte("somesalt"), 2, 64, 2, 32, c)
+	g.Expect(key).Should(gomega.Equal(argon2.IDKey([]byte("password"), []byte("somesalt"), 2, 64, 2, 32)))
+	g.Expect(calls).Should(gomega.Equal([][2]uint32{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 0}, {1, 1}, {1, 2}, {1, 3}}))
+}
@@ -0,0 +1,96 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2
+
+import "sync"
+
+// Pool keeps working memory for reuse by later derivations. Memory is grouped into size classes, so that one arena
+// serves every amount of memory up to its class, and is zeroed when it is returned. A Pool is safe for concurrent
+// use.
+type Pool struct {
+	maxIdle uint64
+
+	mu   sync.Mutex
+	idle uint64
+	free map[uint32][][]block
+}
+
+// NewPool returns a Pool that keeps at most maxIdle KiB of memory that is not in use. An arena returned while the
+// Pool is full is left to the garbage collector.
+func NewPool(maxIdle uint64) *Pool {
+	return &Pool{maxIdle: maxIdle, free: make(map[uint32][][]block)}
+}
+
+// sizeClass rounds memory up to 4, 5, 6 or 7 quarters of a power of two, so that no more than a quarter of an arena
+// is unused
+func sizeClass(memory uint32) uint32 {
+	if memory <= 8 {
+		return memory
+	}
+
+	shift := uint(0)
+	for (memory-1)>>shift >= 8 {
+		shift++
+	}
+
+	return ((memory-1)>>shift + 1) << shift
+}
+
+// get returns memory zeroed blocks
+func (p *Pool) get(memory uint32) []block {
+	class := sizeClass(memory)
+
+	p.mu.Lock()
+	arenas := p.free[class]
+	if n := len(arenas); n > 0 {
+		B := arenas[n-1]
+		arenas[n-1] = nil
+		p.free[class] = arenas[:n-1]
+		p.idle -= uint64(class)
+		p.mu.Unlock()
+		return B[:memory]
+	}
+	p.mu.Unlock()
+
+	return newBlocks(class)[:memory]
+}
+
+// put zeroes B and keeps its arena for reuse, if there is room
+func (p *Pool) put(B []block) {
+	wipeBlocks(B)
+
+	class := uint32(cap(B))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idle+uint64(class) > p.maxIdle {
+		return
+	}
+
+	p.free[class] = append(p.free[class], B[:class])
+	p.idle += uint64(class)
+}
+
+// Idle returns the amount of memory, in KiB, that the Pool is keeping for reuse
+func (p *Pool) Idle() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.idle
+}
@@ -0,0 +1,86 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestSizeClass(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := map[uint32]uint32{
+		8:     8,
+		9:     10,
+		16:    16,
+		17:    20,
+		64:    64,
+		1000:  1024,
+		19456: 20480,
+		47104: 49152,
+		65536: 65536,
+		65537: 81920,
+	}
+
+	for memory, class := range tests {
+		g.Expect(sizeClass(memory)).Should(gomega.Equal(class), "%d", memory)
+		g.Expect(memory*5/4 >= sizeClass(memory)).Should(gomega.BeTrue(), "%d", memory)
+	}
+}
+
+func TestPool(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer func(f func(uint32) []block) { newBlocks = f }(newBlocks)
+
+	var arenas [][]block
+	newBlocks = func(memory uint32) []block {
+		B := make([]block, memory)
+		arenas = append(arenas, B)
+		return B
+	}
+
+	pool := NewPool(1024)
+	c := Config{Pool: pool}
+
+	for _, memory := range []uint32{1000, 1024, 960} {
+		want := argon2.IDKey([]byte("password"), []byte("somesalt"), 2, memory, 2, 32)
+		g.Expect(IDKey([]byte("password"), []byte("somesalt"), 2, memory, 2, 32, c)).Should(gomega.Equal(want))
+	}
+
+	// the same size class, so a single arena is reused and it is zeroed every time
+	g.Expect(arenas).Should(gomega.HaveLen(1))
+	g.Expect(arenas[0]).Should(gomega.HaveLen(1024))
+	for i := range arenas[0] {
+		g.Expect(arenas[0][i]).Should(gomega.Equal(block{}), "block %d", i)
+	}
+	g.Expect(pool.Idle()).Should(gomega.Equal(uint64(1024)))
+
+	// a second size class does not fit alongside the first
+	IDKey([]byte("password"), []byte("somesalt"), 1, 64, 1, 32, c)
+	g.Expect(arenas).Should(gomega.HaveLen(2))
+	g.Expect(pool.Idle()).Should(gomega.Equal(uint64(1024)))
+
+	// nor does an arena bigger than the pool
+	IDKey([]byte("password"), []byte("somesalt"), 1, 2048, 1, 32, c)
+	g.Expect(arenas).Should(gomega.HaveLen(3))
+	g.Expect(pool.Idle()).Should(gomega.Equal(uint64(1024)))
+}
@@ -0,0 +1,70 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2
+
+import "sync"
+
+// Workers is a fixed number of goroutines that compute the lanes of every derivation that uses them, so that the
+// parallelism of a hash does not decide how many goroutines compete for the CPUs. A Workers is safe for concurrent
+// use.
+type Workers struct {
+	tasks chan func()
+	quit  chan struct{}
+	once  sync.Once
+}
+
+// NewWorkers starts n goroutines, or one if n is less than one
+func NewWorkers(n int) *Workers {
+	if n < 1 {
+		n = 1
+	}
+
+	w := &Workers{tasks: make(chan func()), quit: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		go w.work()
+	}
+
+	return w
+}
+
+func (w *Workers) work() {
+	for {
+		select {
+		case task := <-w.tasks:
+			task()
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// run hands task to a worker, waiting for one to be free. Once Stop has been called, task is run by the caller.
+func (w *Workers) run(task func()) {
+	select {
+	case w.tasks <- task:
+	case <-w.quit:
+		task()
+	}
+}
+
+// Stop ends the goroutines once they finish their current task. Derivations still using w compute their lanes
+// one at a time on their own goroutine.
+func (w *Workers) Stop() {
+	w.once.Do(func() { close(w.quit) })
+}
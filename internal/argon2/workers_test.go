@@ -0,0 +1,54 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestWorkers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, n := range []int{0, 1, 3} {
+		workers := NewWorkers(n)
+		c := Config{Workers: workers}
+
+		// more lanes than workers, and several derivations sharing them
+		var wg sync.WaitGroup
+		for threads := uint8(1); threads <= 8; threads++ {
+			wg.Add(1)
+			go func(threads uint8) {
+				defer wg.Done()
+				want := argon2.IDKey([]byte("password"), []byte("somesalt"), 2, 256, threads, 32)
+				g.Expect(IDKey([]byte("password"), []byte("somesalt"), 2, 256, threads, 32, c)).Should(gomega.Equal(want), "%d workers, %d threads", n, threads)
+			}(threads)
+		}
+		wg.Wait()
+
+		// once stopped, the lanes are computed by the caller
+		workers.Stop()
+		workers.Stop()
+		want := argon2.IDKey([]byte("password"), []byte("somesalt"), 1, 64, 4, 32)
+		g.Expect(IDKey([]byte("password"), []byte("somesalt"), 1, 64, 4, 32, c)).Should(gomega.Equal(want))
+	}
+}
@@ -0,0 +1,122 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package protocol defines the messages exchanged between the argon2id daemon and its client. Each message is a
+// single line of JSON; a connection carries one request and then its response at a time.
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// DefaultAddress is where the daemon listens and the client connects if no address is given.
+const DefaultAddress = "unix:/run/argon2id/argon2id.sock"
+
+// MaxMessageSize is the longest line, in bytes, that either side will read.
+const MaxMessageSize = 64 * 1024
+
+// The supported operations
+const (
+	OpHash        = "hash"
+	OpCompare     = "compare"
+	OpNeedsRehash = "needs_rehash"
+)
+
+// The error codes that map back onto the package's sentinel errors
+const (
+	CodeInvalidHash               = "invalid_hash"
+	CodeInvalidComplexity         = "invalid_complexity"
+	CodeInvalidArgon2Version      = "invalid_argon2_version"
+	CodeMismatchedHashAndPassword = "mismatched_hash_and_password"
+	CodeError                     = "error"
+)
+
+// Request is sent by the client. The password is bytes, base64 encoded in JSON, because a JSON string would replace
+// any bytes that are not valid UTF-8.
+type Request struct {
+	Op             string `json:"op"`
+	Password       []byte `json:"password,omitempty"`
+	HashedPassword string `json:"hashed_password,omitempty"`
+	Time           uint32 `json:"time,omitempty"`
+	Memory         uint32 `json:"memory,omitempty"`
+	Threads        uint8  `json:"threads,omitempty"`
+	KeyLen         uint32 `json:"keylen,omitempty"`
+}
+
+// Response is sent by the daemon
+type Response struct {
+	HashedPassword string `json:"hashed_password,omitempty"`
+	NeedsRehash    bool   `json:"needs_rehash,omitempty"`
+	ErrorCode      string `json:"error_code,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+var codes = map[error]string{
+	argon2id.ErrInvalidHash:               CodeInvalidHash,
+	argon2id.ErrInvalidComplexity:         CodeInvalidComplexity,
+	argon2id.ErrInvalidArgon2Version:      CodeInvalidArgon2Version,
+	argon2id.ErrMismatchedHashAndPassword: CodeMismatchedHashAndPassword,
+}
+
+// SetError will fill in the error fields of the response
+func (r *Response) SetError(err error) {
+	code, ok := codes[err]
+	if !ok {
+		code = CodeError
+	}
+
+	r.ErrorCode = code
+	r.Error = err.Error()
+}
+
+// Err will return the error carried by the response, or nil. The package's sentinel errors are returned as the
+// same values so that callers can compare against them.
+func (r *Response) Err() error {
+	if r.ErrorCode == "" {
+		return nil
+	}
+
+	for err, code := range codes {
+		if code == r.ErrorCode {
+			return err
+		}
+	}
+
+	return errors.New(r.Error)
+}
+
+// SplitAddress will split an address such as "unix:/path/to/socket" or "tcp:127.0.0.1:7519" into a network and
+// an address suitable for net.Dial and net.Listen.
+func SplitAddress(address string) (network, addr string, err error) {
+	i := strings.Index(address, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("synacor/argon2id: address %q must be of the form unix:<path> or tcp:<host>:<port>", address)
+	}
+
+	network, addr = address[:i], address[i+1:]
+	switch network {
+	case "unix", "tcp", "tcp4", "tcp6":
+		return network, addr, nil
+	}
+
+	return "", "", fmt.Errorf("synacor/argon2id: unsupported network %q in address %q", network, address)
+}
@@ -0,0 +1,64 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package protocol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestResponseErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, err := range []error{argon2id.ErrInvalidHash, argon2id.ErrInvalidComplexity, argon2id.ErrInvalidArgon2Version, argon2id.ErrMismatchedHashAndPassword} {
+		var r Response
+		r.SetError(err)
+		g.Expect(r.Err()).Should(gomega.Equal(err))
+	}
+
+	var r Response
+	g.Expect(r.Err()).Should(gomega.Succeed())
+
+	r.SetError(errors.New("something else"))
+	g.Expect(r.ErrorCode).Should(gomega.Equal(CodeError))
+	g.Expect(r.Err()).Should(gomega.MatchError("something else"))
+}
+
+func TestSplitAddress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	network, addr, err := SplitAddress("unix:/tmp/argon2id.sock")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(network).Should(gomega.Equal("unix"))
+	g.Expect(addr).Should(gomega.Equal("/tmp/argon2id.sock"))
+
+	network, addr, err = SplitAddress("tcp:127.0.0.1:7519")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(network).Should(gomega.Equal("tcp"))
+	g.Expect(addr).Should(gomega.Equal("127.0.0.1:7519"))
+
+	_, _, err = SplitAddress("/tmp/argon2id.sock")
+	g.Expect(err).ShouldNot(gomega.Succeed())
+
+	_, _, err = SplitAddress("udp:127.0.0.1:7519")
+	g.Expect(err).ShouldNot(gomega.Succeed())
+}
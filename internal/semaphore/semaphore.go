@@ -0,0 +1,117 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package semaphore provides a weighted, FIFO semaphore used to bound concurrent Argon2 work.
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Weighted is a FIFO counting semaphore where each acquirer asks for a number of units. It is used to keep the
+// total Argon2 memory in use under a budget, or with units of "1", to bound the number of concurrent operations.
+type Weighted struct {
+	size    uint64
+	mu      sync.Mutex
+	cur     uint64
+	waiters list.List
+}
+
+type waiter struct {
+	n     uint64
+	ready chan struct{}
+}
+
+// New returns a Weighted semaphore holding size units.
+func New(size uint64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Clamp will reduce n to the size of the semaphore so that a single request larger than the whole budget can
+// still run, just not alongside anything else.
+func (s *Weighted) Clamp(n uint64) uint64 {
+	if n > s.size {
+		return s.size
+	}
+
+	return n
+}
+
+// Acquire blocks until n units are available or ctx is done. On success, the caller must call Release(n).
+func (s *Weighted) Acquire(ctx context.Context, n uint64) error {
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// acquired after the context was cancelled, give it back
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+
+	case <-w.ready:
+		return nil
+	}
+}
+
+// Release returns n units to the semaphore.
+func (s *Weighted) Release(n uint64) {
+	s.mu.Lock()
+	s.cur -= n
+	s.notifyWaiters()
+	s.mu.Unlock()
+}
+
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,40 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package semaphore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestWeightedCancelled(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := New(10)
+	g.Expect(s.Acquire(context.Background(), 8)).Should(gomega.Succeed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.Expect(s.Acquire(ctx, 5)).Should(gomega.Equal(context.Canceled))
+
+	s.Release(8)
+	g.Expect(s.Acquire(context.Background(), 10)).Should(gomega.Succeed())
+}
@@ -0,0 +1,35 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+// Key will derive a key of p.KeyLen bytes from the password and salt with Argon2id, for use as an encryption key
+// rather than a stored password hash. Any "0" value in p is replaced by its sane default. It is reported to the
+// Observer as OperationKey.
+func Key(password, salt []byte, p Params) []byte {
+	return KeyWithProgress(password, salt, p, nil)
+}
+
+// KeyWithProgress is Key, calling progress, if not nil, as the key is derived
+func KeyWithProgress(password, salt []byte, p Params, progress ProgressFunc) []byte {
+	p = p.withDefaults()
+	start := now()
+	key := deriveKey(password, salt, p, progress)
+	observe(OperationKey, start, p, FormatUnknown, nil)
+	return key
+}
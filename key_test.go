@@ -0,0 +1,48 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestKey(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the raw hash of referencePHCHash
+	key := Key([]byte("password"), []byte("somesalt"), Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32})
+	g.Expect(hex.EncodeToString(key)).Should(gomega.Equal("09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"))
+
+	g.Expect(Key([]byte("password"), []byte("somesalt"), Params{Memory: 1024})).Should(gomega.HaveLen(int(defaultKeyLen)))
+
+	var events []Event
+	SetObserver(ObserverFunc(func(e Event) { events = append(events, e) }))
+	defer SetObserver(nil)
+
+	Key([]byte("password"), []byte("somesalt"), Params{Memory: 1024, KeyLen: 16})
+	g.Expect(events).Should(gomega.HaveLen(1))
+	g.Expect(events[0].Operation).Should(gomega.Equal(OperationKey))
+	g.Expect(events[0].Operation.String()).Should(gomega.Equal("key"))
+	g.Expect(events[0].Params).Should(gomega.Equal(Params{Time: defaultTime, Memory: 1024, Threads: defaultThreads, KeyLen: 16}))
+	g.Expect(events[0].Format).Should(gomega.Equal(FormatUnknown))
+	g.Expect(events[0].Outcome).Should(gomega.Equal(OutcomeSuccess))
+}
@@ -0,0 +1,65 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCompareLDAP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h := "{ARGON2}" + referencePHCHash
+	g.Expect(DetectFormat(h)).Should(gomega.Equal(FormatLDAP))
+	g.Expect(Compare(h, "password")).Should(gomega.Succeed())
+	g.Expect(Compare(h, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	// scheme names are case insensitive
+	g.Expect(Compare("{Argon2}"+referencePHCHash, "password")).Should(gomega.Succeed())
+
+	// and the case a hash was written with is kept
+	converted, err := Convert("{Argon2}"+referencePHCHash, FormatLDAP)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal("{Argon2}" + referencePHCHash))
+
+	data, err := Hash("{Argon2}" + referencePHCHash).MarshalBinary()
+	g.Expect(err).Should(gomega.Succeed())
+	var back Hash
+	g.Expect(back.UnmarshalBinary(data)).Should(gomega.Succeed())
+	g.Expect(back).Should(gomega.Equal(Hash("{Argon2}" + referencePHCHash)))
+
+	converted, err = Convert("{Argon2}"+referencePHCHash, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+	g.Expect(Compare("{ARGON2}"+testHash, "my-password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(Compare("{ARGON2}", "password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(DetectFormat("{ARGON2}bad-hash")).Should(gomega.Equal(FormatUnknown))
+
+	converted, err = Convert(h, FormatPHC)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+	converted, err = Convert(testHash, FormatLDAP)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.HavePrefix("{ARGON2}$argon2id$v=19$m=65536,t=1,p=4$"))
+	g.Expect(Compare(converted, "my-password")).Should(gomega.Succeed())
+}
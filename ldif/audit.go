@@ -0,0 +1,98 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ldif
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// Finding is a userPassword value that does not meet the policy. It never contains the value itself.
+type Finding struct {
+	DN      string
+	Line    int
+	Problem string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s: %s", f.Line, f.DN, f.Problem)
+}
+
+// Audit will read every entry from r and return a Finding for each userPassword that is not an {ARGON2} hash, cannot
+// be parsed, or was created with parameters below policy. A "0" value in policy is not checked. Entries without a
+// userPassword are skipped.
+func Audit(r io.Reader, policy argon2id.Params) ([]Finding, error) {
+	var findings []Finding
+	reader := NewReader(r)
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return findings, nil
+		}
+		if err != nil {
+			return findings, err
+		}
+
+		for _, value := range entry.Values(UserPassword) {
+			if problem := auditValue(value, policy); problem != "" {
+				findings = append(findings, Finding{DN: entry.DN, Line: entry.Line, Problem: problem})
+			}
+		}
+	}
+}
+
+// ldapScheme is the scheme of OpenLDAP's pw-argon2 module. It is checked here rather than with DetectFormat, which
+// reads a lower case "{argon2}" as the Spring Security format.
+const ldapScheme = "{ARGON2}"
+
+func auditValue(value string, policy argon2id.Params) string {
+	if len(value) < len(ldapScheme) || !strings.EqualFold(value[:len(ldapScheme)], ldapScheme) || argon2id.DetectFormat(value[len(ldapScheme):]) != argon2id.FormatPHC {
+		if strings.HasPrefix(value, "{") {
+			if end := strings.Index(value, "}"); end > 0 && !strings.EqualFold(value[:end+1], ldapScheme) {
+				return fmt.Sprintf("uses the %s scheme", value[:end+1])
+			}
+		}
+
+		return "is not an {ARGON2} argon2id hash"
+	}
+
+	p, err := argon2id.GetParams(value)
+	if err != nil {
+		return err.Error()
+	}
+
+	var below []string
+	if p.Time < policy.Time {
+		below = append(below, fmt.Sprintf("t=%d is below %d", p.Time, policy.Time))
+	}
+	if p.Memory < policy.Memory {
+		below = append(below, fmt.Sprintf("m=%d is below %d", p.Memory, policy.Memory))
+	}
+	if p.Threads < policy.Threads {
+		below = append(below, fmt.Sprintf("p=%d is below %d", p.Threads, policy.Threads))
+	}
+	if p.KeyLen < policy.KeyLen {
+		below = append(below, fmt.Sprintf("hash length %d is below %d", p.KeyLen, policy.KeyLen))
+	}
+
+	return strings.Join(below, ", ")
+}
@@ -0,0 +1,71 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ldif
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestAudit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	input := "dn: uid=alice,dc=example,dc=com\n" +
+		"userPassword: {ARGON2}" + referencePHCHash + "\n" +
+		"\n" +
+		"dn: uid=bob,dc=example,dc=com\n" +
+		"userPassword: {SSHA}c2VjcmV0\n" +
+		"\n" +
+		"dn: uid=carol,dc=example,dc=com\n" +
+		"userPassword: cleartext\n" +
+		"\n" +
+		"dn: uid=dave,dc=example,dc=com\n" +
+		"userPassword: {ARGON2}$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc\n" +
+		"\n" +
+		"dn: uid=erin,dc=example,dc=com\n" +
+		"userPassword: {ARGON2}$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc\n" +
+		"\n" +
+		"dn: uid=frank,dc=example,dc=com\n" +
+		"userPassword: {argon2}" + referencePHCHash + "\n" +
+		"\n" +
+		"dn: ou=people,dc=example,dc=com\n" +
+		"objectClass: organizationalUnit\n"
+
+	findings, err := Audit(strings.NewReader(input), argon2id.Params{})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(findings).Should(gomega.Equal([]Finding{
+		{DN: "uid=bob,dc=example,dc=com", Line: 4, Problem: "uses the {SSHA} scheme"},
+		{DN: "uid=carol,dc=example,dc=com", Line: 7, Problem: "is not an {ARGON2} argon2id hash"},
+		{DN: "uid=dave,dc=example,dc=com", Line: 10, Problem: "is not an {ARGON2} argon2id hash"},
+		{DN: "uid=erin,dc=example,dc=com", Line: 13, Problem: argon2id.ErrInvalidArgon2Version.Error()},
+	}))
+	g.Expect(findings[0].String()).Should(gomega.Equal("line 4: uid=bob,dc=example,dc=com: uses the {SSHA} scheme"))
+
+	findings, err = Audit(strings.NewReader(input[:strings.Index(input, "\n\n")]), argon2id.Params{Time: 3, Memory: 64 * 1024, Threads: 2, KeyLen: 32})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(findings).Should(gomega.Equal([]Finding{
+		{DN: "uid=alice,dc=example,dc=com", Line: 1, Problem: "t=2 is below 3, p=1 is below 2"},
+	}))
+
+	_, err = Audit(strings.NewReader("bogus\n"), argon2id.Params{})
+	g.Expect(err).ShouldNot(gomega.Succeed())
+}
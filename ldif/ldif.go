@@ -0,0 +1,233 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package ldif reads and writes the userPassword attributes of LDIF (RFC 2849) files, so that argon2id hashes can be
+// provisioned into an OpenLDAP directory using the pw-argon2 module's {ARGON2} scheme, and so that an exported
+// directory can be audited against a parameter policy.
+package ldif
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// UserPassword is the name of the attribute that holds password hashes
+const UserPassword = "userPassword"
+
+// Attribute is a single attribute value of an entry
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// Entry is a record of an LDIF file. Change records are read as entries too, with their changetype and
+// modification lines as attributes.
+type Entry struct {
+	DN         string
+	Attributes []Attribute
+
+	// Line is the line number the entry started on
+	Line int
+}
+
+// Values will return the values of the attribute with the given name, which is matched case insensitively
+func (e *Entry) Values(name string) []string {
+	var values []string
+	for _, a := range e.Attributes {
+		if strings.EqualFold(a.Name, name) {
+			values = append(values, a.Value)
+		}
+	}
+
+	return values
+}
+
+// Reader reads entries from an LDIF file
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	// pending is a line that was read ahead while unfolding continuation lines
+	pending     string
+	pendingLine int
+	hasPending  bool
+}
+
+// NewReader will return a Reader that reads from r
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+// Next will return the next entry, or io.EOF when there are no more
+func (r *Reader) Next() (*Entry, error) {
+	var entry *Entry
+	for {
+		line, lineNumber, ok, err := r.readLogicalLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok || line == "" {
+			if entry != nil {
+				return entry, nil
+			}
+			if !ok {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		attr, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("synacor/argon2id: line %d: %v", lineNumber, err)
+		}
+
+		if entry == nil {
+			if strings.EqualFold(attr.Name, "version") {
+				continue
+			}
+			if !strings.EqualFold(attr.Name, "dn") {
+				return nil, fmt.Errorf("synacor/argon2id: line %d: an entry must start with dn", lineNumber)
+			}
+			entry = &Entry{DN: attr.Value, Line: lineNumber}
+			continue
+		}
+
+		entry.Attributes = append(entry.Attributes, attr)
+	}
+}
+
+// readLogicalLine will read a line and unfold any continuation lines, which start with a single space
+func (r *Reader) readLogicalLine() (line string, lineNumber int, ok bool, err error) {
+	line, lineNumber, ok = r.readPhysicalLine()
+	if !ok {
+		return "", 0, false, r.scanner.Err()
+	}
+
+	for {
+		next, nextNumber, more := r.readPhysicalLine()
+		if !more {
+			return line, lineNumber, true, r.scanner.Err()
+		}
+
+		if !strings.HasPrefix(next, " ") {
+			r.pending, r.pendingLine, r.hasPending = next, nextNumber, true
+			return line, lineNumber, true, nil
+		}
+
+		line += next[1:]
+	}
+}
+
+func (r *Reader) readPhysicalLine() (string, int, bool) {
+	if r.hasPending {
+		r.hasPending = false
+		return r.pending, r.pendingLine, true
+	}
+
+	if !r.scanner.Scan() {
+		return "", 0, false
+	}
+
+	r.line++
+	return strings.TrimSuffix(r.scanner.Text(), "\r"), r.line, true
+}
+
+// parseLine will parse "name: value", "name:: base64-value" and the "-" separator of change records
+func parseLine(line string) (Attribute, error) {
+	if line == "-" {
+		return Attribute{Name: "-"}, nil
+	}
+
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return Attribute{}, fmt.Errorf("missing attribute name")
+	}
+
+	name, value := line[:i], line[i+1:]
+	switch {
+	case strings.HasPrefix(value, ":"):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+		if err != nil {
+			return Attribute{}, fmt.Errorf("invalid base64 value of %s: %v", name, err)
+		}
+		value = string(decoded)
+	case strings.HasPrefix(value, "<"):
+		return Attribute{}, fmt.Errorf("URL values of %s are not supported", name)
+	default:
+		value = strings.TrimLeft(value, " ")
+	}
+
+	return Attribute{Name: name, Value: value}, nil
+}
+
+// WriteUserPassword will write an LDIF change record that replaces the userPassword of dn with hashedPassword. The
+// hash is written with the {ARGON2} scheme prefix.
+func WriteUserPassword(w io.Writer, dn, hashedPassword string) error {
+	value, err := argon2id.Convert(hashedPassword, argon2id.FormatLDAP)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\nchangetype: modify\nreplace: %s\n%s\n-\n\n", formatLine("dn", dn), UserPassword, formatLine(UserPassword, value))
+	return err
+}
+
+// formatLine will write "name: value", or "name:: base64-value" if the value is not safe to write as is
+func formatLine(name, value string) string {
+	if !isSafe(value) {
+		return name + ":: " + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	return name + ": " + value
+}
+
+// isSafe implements the SAFE-STRING rule of RFC 2849
+func isSafe(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	switch value[0] {
+	case ' ', ':', '<':
+		return false
+	}
+
+	if value[len(value)-1] == ' ' {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == 0 || c == '\n' || c == '\r' || c > 127 {
+			return false
+		}
+	}
+
+	return true
+}
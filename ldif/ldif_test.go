@@ -0,0 +1,105 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ldif
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+const referencePHCHash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestReader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	input := "version: 1\n" +
+		"# a comment\n" +
+		"dn: uid=alice,ou=people,dc=example,dc=com\n" +
+		"objectClass: inetOrgPerson\n" +
+		"userPassword: {ARGON2}$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aF\n" +
+		" aMaO6Mm5c8y7cJHAph8ArZWb2GRPPc\n" +
+		"\n" +
+		"\n" +
+		"dn:: dWlkPWJvYixvdT1wZW9wbGUsZGM9ZXhhbXBsZSxkYz1jb20=\r\n" +
+		"userpassword:: e1NTSEF9c2VjcmV0\r\n"
+
+	r := NewReader(strings.NewReader(input))
+
+	entry, err := r.Next()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(entry.DN).Should(gomega.Equal("uid=alice,ou=people,dc=example,dc=com"))
+	g.Expect(entry.Line).Should(gomega.Equal(3))
+	g.Expect(entry.Values("objectclass")).Should(gomega.Equal([]string{"inetOrgPerson"}))
+	g.Expect(entry.Values(UserPassword)).Should(gomega.Equal([]string{"{ARGON2}" + referencePHCHash}))
+
+	entry, err = r.Next()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(entry.DN).Should(gomega.Equal("uid=bob,ou=people,dc=example,dc=com"))
+	g.Expect(entry.Line).Should(gomega.Equal(9))
+	g.Expect(entry.Values(UserPassword)).Should(gomega.Equal([]string{"{SSHA}secret"}))
+
+	_, err = r.Next()
+	g.Expect(err).Should(gomega.Equal(io.EOF))
+}
+
+func TestReaderFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := NewReader(strings.NewReader("cn: alice\n")).Next()
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: line 1: an entry must start with dn"))
+
+	_, err = NewReader(strings.NewReader("dn: cn=alice\nbogus\n")).Next()
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: line 2: missing attribute name"))
+
+	_, err = NewReader(strings.NewReader("dn: cn=alice\nuserPassword:: !!!\n")).Next()
+	g.Expect(err).Should(gomega.MatchError(gomega.HavePrefix("synacor/argon2id: line 2: invalid base64 value of userPassword")))
+
+	_, err = NewReader(strings.NewReader("dn: cn=alice\nuserPassword:< file:///etc/passwd\n")).Next()
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: line 2: URL values of userPassword are not supported"))
+}
+
+func TestWriteUserPassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	buf := bytes.NewBuffer(nil)
+	g.Expect(WriteUserPassword(buf, "uid=alice,ou=people,dc=example,dc=com", referencePHCHash)).Should(gomega.Succeed())
+	g.Expect(buf.String()).Should(gomega.Equal("dn: uid=alice,ou=people,dc=example,dc=com\n" +
+		"changetype: modify\n" +
+		"replace: userPassword\n" +
+		"userPassword: {ARGON2}" + referencePHCHash + "\n" +
+		"-\n\n"))
+
+	// the record can be read back
+	entry, err := NewReader(buf).Next()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(entry.Values(UserPassword)).Should(gomega.Equal([]string{"{ARGON2}" + referencePHCHash}))
+	g.Expect(argon2id.Compare(entry.Values(UserPassword)[0], "password")).Should(gomega.Succeed())
+
+	// DNs that are not safe strings are base64 encoded
+	buf.Reset()
+	g.Expect(WriteUserPassword(buf, "uid=jürgen,dc=example,dc=com", referencePHCHash)).Should(gomega.Succeed())
+	g.Expect(buf.String()).Should(gomega.HavePrefix("dn:: dWlkPWrDvHJnZW4sZGM9ZXhhbXBsZSxkYz1jb20=\n"))
+
+	g.Expect(WriteUserPassword(buf, "uid=alice", "bad-hash")).Should(gomega.Equal(argon2id.ErrInvalidHash))
+}
@@ -0,0 +1,122 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// stdEncoding is the standard base64 alphabet that earlier releases of this library used
+var stdEncoding = base64.RawStdEncoding
+
+// decodeSaltAndHash will work out which alphabet the salt and hash were encoded with and decode them. "+" only exists
+// in the standard alphabet and "." only in the crypt alphabet. Otherwise, because this library always writes canonical
+// base64 and the two alphabets are offset from each other, a value is almost never canonical in both, so strict
+// decoding tells them apart.
+func decodeSaltAndHash(salt, hash string) (format Format, rawSalt, rawHash []byte, err error) {
+	hasPlus := strings.Contains(salt, "+") || strings.Contains(hash, "+")
+	hasDot := strings.Contains(salt, ".") || strings.Contains(hash, ".")
+
+	switch {
+	case hasPlus && hasDot:
+		return FormatUnknown, nil, nil, ErrInvalidHash
+	case hasPlus:
+		format = FormatSynacorStd
+	case hasDot:
+		format = FormatSynacor
+	case decodesStrictly(encoding, salt, hash):
+		format = FormatSynacor
+	case decodesStrictly(stdEncoding, salt, hash):
+		format = FormatSynacorStd
+	default:
+		format = FormatSynacor
+	}
+
+	enc := encoding
+	if format == FormatSynacorStd {
+		enc = stdEncoding
+	}
+
+	if rawHash, err = enc.DecodeString(hash); err != nil {
+		return FormatUnknown, nil, nil, err
+	}
+
+	if rawSalt, err = enc.DecodeString(salt); err != nil {
+		return FormatUnknown, nil, nil, err
+	}
+
+	return format, rawSalt, rawHash, nil
+}
+
+func decodesStrictly(enc *base64.Encoding, values ...string) bool {
+	for _, v := range values {
+		if _, err := enc.Strict().DecodeString(v); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// legacyAlternative will return the hash read with the standard alphabet if its string form could also have been
+// written by an earlier release, or nil if it could not. That only happens when neither the salt nor the hash have
+// spare bits for decodeSaltAndHash to look at.
+func (h *hashed) legacyAlternative() *hashed {
+	if h.format != FormatSynacor {
+		return nil
+	}
+
+	salt, hash := encoding.EncodeToString(h.salt), encoding.EncodeToString(h.hash)
+	if strings.Contains(salt, ".") || strings.Contains(hash, ".") || !decodesStrictly(stdEncoding, salt, hash) {
+		return nil
+	}
+
+	alt := *h
+	alt.format = FormatSynacorStd
+	alt.salt, _ = stdEncoding.DecodeString(salt)
+	alt.hash, _ = stdEncoding.DecodeString(hash)
+	return &alt
+}
+
+// CompareNeedsRehash will compare the hashedPassword with the supplied password like Compare. On success, it also
+// reports whether the hash should be replaced with a new one from HashPassword, either because it was not created
+// with time, memory, threads and keyLen ("0" meaning the sane default), or because it uses the standard base64
+// alphabet of earlier releases. Unlike NeedsRehash, it can tell which alphabet a hash uses in every case. A hash whose
+// alphabet cannot be told from the string is always computed in both alphabets, so the time taken does not depend on
+// which one matched.
+func CompareNeedsRehash(hashedPassword, password string, time, memory uint32, threads uint8, keyLen uint32) (needsRehash bool, err error) {
+	start := now()
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		observe(OperationCompare, start, Params{}, DetectFormat(hashedPassword), err)
+		return false, err
+	}
+
+	pw := []byte(password)
+	defer wipe(pw)
+	defer h.wipe()
+	err = h.compare(pw)
+	observe(OperationCompare, start, h.params(), h.format, err)
+	if err != nil {
+		return false, err
+	}
+
+	return h.needsRehash(Params{Time: time, Memory: memory, Threads: threads, KeyLen: keyLen}), nil
+}
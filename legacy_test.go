@@ -0,0 +1,144 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// hashes written with the standard base64 alphabet of earlier releases
+const (
+	// contains a "+"
+	legacyHashWithPlus = "$argon2id19$1,1024,1$MDEyMzQ1Njc4OWFiY2RlZg$hgAeTUfaJkVMi2Q+Z07XQENx1JQNH8IqMxSijk7+PXs"
+
+	// only uses characters that both alphabets share, but is only canonical in the standard one
+	legacyHash = "$argon2id19$1,1024,1$MDAwMDAwMDAwMDAwMDAwMA$qUgg6A16WZCMlj2CcMlWNLcciIaJvZCvthVkgoczRfI"
+
+	// a 15 byte salt and a 24 byte hash have no spare bits, so this can only be told apart by comparing
+	legacyHashAmbiguous = "$argon2id19$1,1024,1$MDAwMDAwMDAwMDAwMDAw$zXMiDxjeOpCDBZBw4DHdbwQ8fEPZuq4P"
+
+	// the same salt in the current alphabet, with a hash of "current-0" that is just as ambiguous
+	currentHashAmbiguous = "$argon2id19$1,1024,1$MDAwMDAwMDAwMDAwMDAw$3FoQVu1rejgMuZnoUjAcQzwgw7CPu7Fc"
+)
+
+func TestCompareLegacy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(IsHashedPassword(legacyHashWithPlus)).Should(gomega.BeTrue())
+	g.Expect(Compare(legacyHashWithPlus, "legacy-1")).Should(gomega.Succeed())
+	g.Expect(Compare(legacyHashWithPlus, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(DetectFormat(legacyHashWithPlus)).Should(gomega.Equal(FormatSynacorStd))
+
+	g.Expect(Compare(legacyHash, "legacy-password")).Should(gomega.Succeed())
+	g.Expect(DetectFormat(legacyHash)).Should(gomega.Equal(FormatSynacorStd))
+
+	g.Expect(Compare(legacyHashAmbiguous, "legacy-password")).Should(gomega.Succeed())
+	g.Expect(Compare(legacyHashAmbiguous, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(DetectFormat(legacyHashAmbiguous)).Should(gomega.Equal(FormatSynacor))
+
+	// a "+" and a "." can never appear together
+	g.Expect(Compare("$argon2id19$1,1024,1$MDEyMzQ1Njc4OWFiY2RlZg$hgAeTUfaJkVMi2Q+Z07XQENx1JQNH8IqMxSijk7.PXs", "legacy-1")).Should(gomega.Equal(ErrInvalidHash))
+}
+
+func TestCompareAmbiguousTiming(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	o := &begunObserver{}
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	// both candidates are computed whichever one matches, if any
+	for _, tc := range []struct {
+		hashedPassword, password string
+		err                      error
+	}{
+		{currentHashAmbiguous, "current-0", nil},
+		{legacyHashAmbiguous, "legacy-password", nil},
+		{currentHashAmbiguous, "bad-password", ErrMismatchedHashAndPassword},
+	} {
+		o.begun = nil
+		if err := Compare(tc.hashedPassword, tc.password); tc.err == nil {
+			g.Expect(err).Should(gomega.Succeed())
+		} else {
+			g.Expect(err).Should(gomega.Equal(tc.err))
+		}
+		g.Expect(o.begun).Should(gomega.HaveLen(2))
+	}
+
+	needsRehash, err := CompareNeedsRehash(currentHashAmbiguous, "current-0", 1, 1024, 1, 24)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	// a hash that can be told apart is computed once
+	o.begun = nil
+	g.Expect(Compare(legacyHash, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+	g.Expect(o.begun).Should(gomega.HaveLen(1))
+}
+
+func TestLegacyNeedsRehash(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, h := range []string{legacyHashWithPlus, legacyHash} {
+		needsRehash, err := NeedsRehash(h, 1, 1024, 1, 32)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(needsRehash).Should(gomega.BeTrue(), h)
+	}
+
+	// the alphabet is only known once the password has been compared
+	needsRehash, err := NeedsRehash(legacyHashAmbiguous, 1, 1024, 1, 24)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	needsRehash, err = CompareNeedsRehash(legacyHashAmbiguous, "legacy-password", 1, 1024, 1, 24)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeTrue())
+
+	current, _ := HashPassword("test", 1, 1024, 1, 16)
+	needsRehash, err = CompareNeedsRehash(current, "test", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	needsRehash, err = CompareNeedsRehash(current, "test", 2, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeTrue())
+
+	_, err = CompareNeedsRehash(current, "bad-password", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	_, err = CompareNeedsRehash("bad-hash", "test", 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+}
+
+func TestLegacyBinary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, s := range []string{legacyHashWithPlus, legacyHash, legacyHashAmbiguous} {
+		data, err := Hash(s).MarshalBinary()
+		g.Expect(err).Should(gomega.Succeed())
+
+		var h Hash
+		g.Expect(h.UnmarshalBinary(data)).Should(gomega.Succeed())
+		g.Expect(h).Should(gomega.Equal(Hash(s)))
+	}
+
+	data, _ := Hash(legacyHashAmbiguous).MarshalBinary()
+	g.Expect(CompareBinary(data, "legacy-password")).Should(gomega.Succeed())
+}
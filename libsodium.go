@@ -0,0 +1,37 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+// The presets of libsodium's crypto_pwhash_argon2id, whose crypto_pwhash_str writes PHC strings that Compare verifies.
+// libsodium always uses a single thread and 32 byte hashes.
+var (
+	// LibsodiumInteractive is OPSLIMIT_INTERACTIVE and MEMLIMIT_INTERACTIVE (64 MiB)
+	LibsodiumInteractive = Params{Time: 2, Memory: 64 * 1024, Threads: 1, KeyLen: 32}
+
+	// LibsodiumModerate is OPSLIMIT_MODERATE and MEMLIMIT_MODERATE (256 MiB)
+	LibsodiumModerate = Params{Time: 3, Memory: 256 * 1024, Threads: 1, KeyLen: 32}
+
+	// LibsodiumSensitive is OPSLIMIT_SENSITIVE and MEMLIMIT_SENSITIVE (1 GiB)
+	LibsodiumSensitive = Params{Time: 4, Memory: 1024 * 1024, Threads: 1, KeyLen: 32}
+)
+
+// LibsodiumParams will return the parameters that crypto_pwhash_str uses for an opslimit and a memlimit in bytes
+func LibsodiumParams(opsLimit uint32, memLimit uint64) Params {
+	return Params{Time: opsLimit, Memory: uint32(memLimit / 1024), Threads: 1, KeyLen: 32}
+}
@@ -0,0 +1,85 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+type libsodiumVector struct {
+	Password string `json:"password"`
+	OpsLimit uint32 `json:"opslimit"`
+	MemLimit uint64 `json:"memlimit"`
+	Hash     string `json:"hash"`
+}
+
+func readLibsodiumVectors(t *testing.T) []libsodiumVector {
+	data, err := ioutil.ReadFile("testdata/libsodium.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file struct {
+		Vectors []libsodiumVector `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Vectors
+}
+
+func TestCompareLibsodium(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	vectors := readLibsodiumVectors(t)
+	g.Expect(vectors).ShouldNot(gomega.BeEmpty())
+
+	for _, v := range vectors {
+		g.Expect(DetectFormat(v.Hash)).Should(gomega.Equal(FormatPHC), v.Hash)
+		g.Expect(Compare(v.Hash, v.Password)).Should(gomega.Succeed(), v.Hash)
+		g.Expect(Compare(v.Hash, v.Password+"x")).Should(gomega.Equal(ErrMismatchedHashAndPassword), v.Hash)
+
+		p := LibsodiumParams(v.OpsLimit, v.MemLimit)
+		g.Expect(GetParams(v.Hash)).Should(gomega.Equal(p), v.Hash)
+		g.Expect(NeedsRehash(v.Hash, p.Time, p.Memory, p.Threads, p.KeyLen)).Should(gomega.BeFalse(), v.Hash)
+
+		// a round trip through the other formats keeps the hash verifiable
+		converted, err := Convert(v.Hash, FormatSynacor)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(Convert(converted, FormatPHC)).Should(gomega.Equal(v.Hash))
+	}
+}
+
+func TestLibsodiumPresets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(LibsodiumParams(2, 67108864)).Should(gomega.Equal(LibsodiumInteractive))
+	g.Expect(LibsodiumParams(3, 268435456)).Should(gomega.Equal(LibsodiumModerate))
+	g.Expect(LibsodiumParams(4, 1073741824)).Should(gomega.Equal(LibsodiumSensitive))
+
+	// the hashes have the shape that crypto_pwhash_str_verify expects: a 16 byte salt and a 32 byte hash
+	hashedPassword, err := Hasher{Params: Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32}, Format: FormatPHC}.Hash("password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(hashedPassword).Should(gomega.MatchRegexp(`^\$argon2id\$v=19\$m=1024,t=1,p=1\$[+/a-zA-Z0-9]{22}\$[+/a-zA-Z0-9]{43}$`))
+}
@@ -0,0 +1,307 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package metrics collects hashing statistics from the argon2id package's observer hooks and publishes them through
+// expvar and in the Prometheus text exposition format.
+//
+//	collector := metrics.Install()
+//	collector.Register(mux, "/metrics")
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/synacor/argon2id"
+)
+
+// Buckets are the upper bounds, in seconds, of the latency histograms of Collectors created after it is changed
+var Buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var operations = []argon2id.Operation{argon2id.OperationHash, argon2id.OperationCompare, argon2id.OperationKey}
+
+var outcomes = []argon2id.Outcome{
+	argon2id.OutcomeSuccess,
+	argon2id.OutcomeMismatch,
+	argon2id.OutcomeInvalidHash,
+	argon2id.OutcomeInvalidComplexity,
+	argon2id.OutcomeInvalidVersion,
+	argon2id.OutcomeError,
+}
+
+// The 64-bit fields updated with sync/atomic come first in each struct, and each struct is allocated on its own, so
+// that they are 8-byte aligned on 32-bit platforms too.
+
+type histogram struct {
+	count   uint64
+	sum     int64    // nanoseconds
+	buckets []uint64 // one per bound, not cumulative
+}
+
+// Collector is an argon2id.InFlightObserver that keeps counters, latency histograms and in-flight gauges. It is safe
+// for concurrent use.
+type Collector struct {
+	inFlight  int64
+	memoryKiB int64
+	maxMemKiB int64
+
+	counts    [][]uint64 // [operation][outcome]
+	bounds    []float64
+	latencies []*histogram
+
+	// next is the observer that was installed before the Collector, which still receives every call
+	next argon2id.Observer
+}
+
+// New will return an empty Collector. It does not receive events until it is passed to argon2id.SetObserver.
+func New() *Collector {
+	c := &Collector{
+		counts:    make([][]uint64, len(operations)),
+		bounds:    append([]float64(nil), Buckets...),
+		latencies: make([]*histogram, len(operations)),
+	}
+
+	for i := range operations {
+		c.counts[i] = make([]uint64, len(outcomes))
+		c.latencies[i] = &histogram{buckets: make([]uint64, len(c.bounds))}
+	}
+
+	return c
+}
+
+var installed struct {
+	once      sync.Once
+	collector *Collector
+}
+
+// Install will create a Collector, set it as the argon2id package's observer and publish it to expvar as "argon2id".
+// An observer that was already set keeps receiving every event, and Begin and End if it is an InFlightObserver,
+// through the Collector. Later calls return the same Collector.
+func Install() *Collector {
+	installed.once.Do(func() {
+		installed.collector = New()
+		installed.collector.next = argon2id.CurrentObserver()
+		argon2id.SetObserver(installed.collector)
+		installed.collector.Publish("argon2id")
+	})
+
+	return installed.collector
+}
+
+func operationIndex(op argon2id.Operation) int {
+	for i, o := range operations {
+		if o == op {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func outcomeIndex(outcome argon2id.Outcome) int {
+	for i, o := range outcomes {
+		if o == outcome {
+			return i
+		}
+	}
+
+	return len(outcomes) - 1
+}
+
+// Observe implements argon2id.Observer
+func (c *Collector) Observe(e argon2id.Event) {
+	if c.next != nil {
+		c.next.Observe(e)
+	}
+
+	op := operationIndex(e.Operation)
+	if op < 0 {
+		return
+	}
+
+	atomic.AddUint64(&c.counts[op][outcomeIndex(e.Outcome)], 1)
+
+	h := c.latencies[op]
+	seconds := e.Duration.Seconds()
+	for i, le := range c.bounds {
+		if seconds <= le {
+			atomic.AddUint64(&h.buckets[i], 1)
+			break
+		}
+	}
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(e.Duration))
+}
+
+// Begin implements argon2id.InFlightObserver
+func (c *Collector) Begin(p argon2id.Params) {
+	if next, ok := c.next.(argon2id.InFlightObserver); ok {
+		next.Begin(p)
+	}
+
+	atomic.AddInt64(&c.inFlight, 1)
+	memory := atomic.AddInt64(&c.memoryKiB, int64(p.Memory))
+
+	for {
+		max := atomic.LoadInt64(&c.maxMemKiB)
+		if memory <= max || atomic.CompareAndSwapInt64(&c.maxMemKiB, max, memory) {
+			return
+		}
+	}
+}
+
+// End implements argon2id.InFlightObserver
+func (c *Collector) End(p argon2id.Params) {
+	if next, ok := c.next.(argon2id.InFlightObserver); ok {
+		next.End(p)
+	}
+
+	atomic.AddInt64(&c.inFlight, -1)
+	atomic.AddInt64(&c.memoryKiB, -int64(p.Memory))
+}
+
+// Publish will publish the Collector's statistics to expvar under name. Like expvar.Publish, it panics if name is
+// already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return c.Snapshot() }))
+}
+
+// Snapshot is a point in time copy of a Collector's statistics, as published to expvar
+type Snapshot struct {
+	// Operations counts calls by operation and then by outcome
+	Operations map[string]map[string]uint64 `json:"operations"`
+
+	// Errors counts failed calls of any operation by outcome
+	Errors map[string]uint64 `json:"errors"`
+
+	// Latency holds a histogram per operation
+	Latency map[string]HistogramSnapshot `json:"latency"`
+
+	InFlight          int64 `json:"in_flight"`
+	MemoryInFlight    int64 `json:"memory_in_flight_bytes"`
+	MaxMemoryInFlight int64 `json:"max_memory_in_flight_bytes"`
+}
+
+// HistogramSnapshot is a latency histogram. Buckets maps an upper bound in seconds, formatted as in Prometheus, to
+// the cumulative number of calls that took at most that long.
+type HistogramSnapshot struct {
+	Buckets map[string]uint64 `json:"buckets"`
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum_seconds"`
+}
+
+// Snapshot will return a copy of the current statistics
+func (c *Collector) Snapshot() Snapshot {
+	s := Snapshot{
+		Operations:        make(map[string]map[string]uint64),
+		Errors:            make(map[string]uint64),
+		Latency:           make(map[string]HistogramSnapshot),
+		InFlight:          atomic.LoadInt64(&c.inFlight),
+		MemoryInFlight:    atomic.LoadInt64(&c.memoryKiB) * 1024,
+		MaxMemoryInFlight: atomic.LoadInt64(&c.maxMemKiB) * 1024,
+	}
+
+	for i, op := range operations {
+		byOutcome := make(map[string]uint64)
+		for j, outcome := range outcomes {
+			n := atomic.LoadUint64(&c.counts[i][j])
+			byOutcome[outcome.String()] = n
+			if outcome != argon2id.OutcomeSuccess {
+				s.Errors[outcome.String()] += n
+			}
+		}
+		s.Operations[op.String()] = byOutcome
+
+		h := c.latencies[i]
+		hs := HistogramSnapshot{
+			Buckets: make(map[string]uint64),
+			Count:   atomic.LoadUint64(&h.count),
+			Sum:     time.Duration(atomic.LoadInt64(&h.sum)).Seconds(),
+		}
+		var cumulative uint64
+		for j, le := range c.bounds {
+			cumulative += atomic.LoadUint64(&h.buckets[j])
+			hs.Buckets[formatFloat(le)] = cumulative
+		}
+		s.Latency[op.String()] = hs
+	}
+
+	return s
+}
+
+// WritePrometheus will write the Collector's statistics in the Prometheus text exposition format
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	s := c.Snapshot()
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP argon2id_operations_total Number of argon2id hash and compare calls.")
+	fmt.Fprintln(bw, "# TYPE argon2id_operations_total counter")
+	for _, op := range operations {
+		for _, outcome := range outcomes {
+			fmt.Fprintf(bw, "argon2id_operations_total{operation=%q,outcome=%q} %d\n", op, outcome, s.Operations[op.String()][outcome.String()])
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP argon2id_operation_duration_seconds Duration of argon2id hash and compare calls.")
+	fmt.Fprintln(bw, "# TYPE argon2id_operation_duration_seconds histogram")
+	for _, op := range operations {
+		h := s.Latency[op.String()]
+		for _, le := range c.bounds {
+			fmt.Fprintf(bw, "argon2id_operation_duration_seconds_bucket{operation=%q,le=%q} %d\n", op, formatFloat(le), h.Buckets[formatFloat(le)])
+		}
+		fmt.Fprintf(bw, "argon2id_operation_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", op, h.Count)
+		fmt.Fprintf(bw, "argon2id_operation_duration_seconds_sum{operation=%q} %s\n", op, formatFloat(h.Sum))
+		fmt.Fprintf(bw, "argon2id_operation_duration_seconds_count{operation=%q} %d\n", op, h.Count)
+	}
+
+	fmt.Fprintln(bw, "# HELP argon2id_operations_in_flight Number of Argon2 computations currently running.")
+	fmt.Fprintln(bw, "# TYPE argon2id_operations_in_flight gauge")
+	fmt.Fprintf(bw, "argon2id_operations_in_flight %d\n", s.InFlight)
+
+	fmt.Fprintln(bw, "# HELP argon2id_memory_in_flight_bytes Argon2 memory used by the computations currently running.")
+	fmt.Fprintln(bw, "# TYPE argon2id_memory_in_flight_bytes gauge")
+	fmt.Fprintf(bw, "argon2id_memory_in_flight_bytes %d\n", s.MemoryInFlight)
+
+	fmt.Fprintln(bw, "# HELP argon2id_max_memory_in_flight_bytes Highest value of argon2id_memory_in_flight_bytes seen.")
+	fmt.Fprintln(bw, "# TYPE argon2id_max_memory_in_flight_bytes gauge")
+	fmt.Fprintf(bw, "argon2id_max_memory_in_flight_bytes %d\n", s.MaxMemoryInFlight)
+
+	return bw.Flush()
+}
+
+// ServeHTTP will write the statistics in the Prometheus text exposition format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
+
+// Register will serve the statistics in the Prometheus text exposition format at pattern on mux
+func (c *Collector) Register(mux *http.ServeMux, pattern string) {
+	mux.Handle(pattern, c)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,147 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+func TestCollector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := New()
+	argon2id.SetObserver(c)
+	defer argon2id.SetObserver(nil)
+
+	h, _ := argon2id.HashPassword("test", 1, 1024, 1, 16)
+	argon2id.Compare(h, "test")
+	argon2id.Compare(h, "bad-password")
+	argon2id.Compare("bad-hash", "test")
+
+	s := c.Snapshot()
+	g.Expect(s.Operations["hash"]["success"]).Should(gomega.Equal(uint64(1)))
+	g.Expect(s.Operations["compare"]["success"]).Should(gomega.Equal(uint64(1)))
+	g.Expect(s.Operations["compare"]["mismatch"]).Should(gomega.Equal(uint64(1)))
+	g.Expect(s.Operations["compare"]["invalid_hash"]).Should(gomega.Equal(uint64(1)))
+	g.Expect(s.Errors).Should(gomega.HaveKeyWithValue("mismatch", uint64(1)))
+	g.Expect(s.Errors).Should(gomega.HaveKeyWithValue("invalid_hash", uint64(1)))
+	g.Expect(s.Errors).ShouldNot(gomega.HaveKey("success"))
+	g.Expect(s.Latency["compare"].Count).Should(gomega.Equal(uint64(3)))
+	g.Expect(s.Latency["compare"].Buckets["10"]).Should(gomega.Equal(uint64(3)))
+	g.Expect(s.InFlight).Should(gomega.Equal(int64(0)))
+	g.Expect(s.MemoryInFlight).Should(gomega.Equal(int64(0)))
+	g.Expect(s.MaxMemoryInFlight).Should(gomega.Equal(int64(1024 * 1024)))
+}
+
+func TestCollectorInFlight(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := New()
+	c.Begin(argon2id.Params{Memory: 1024})
+	c.Begin(argon2id.Params{Memory: 2048})
+	c.End(argon2id.Params{Memory: 1024})
+
+	s := c.Snapshot()
+	g.Expect(s.InFlight).Should(gomega.Equal(int64(1)))
+	g.Expect(s.MemoryInFlight).Should(gomega.Equal(int64(2048 * 1024)))
+	g.Expect(s.MaxMemoryInFlight).Should(gomega.Equal(int64(3072 * 1024)))
+}
+
+func TestWritePrometheus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := New()
+	c.Observe(argon2id.Event{Operation: argon2id.OperationCompare, Outcome: argon2id.OutcomeMismatch, Duration: 30 * time.Millisecond})
+	c.Observe(argon2id.Event{Operation: argon2id.OperationCompare, Outcome: argon2id.OutcomeSuccess, Duration: 2 * time.Second})
+
+	mux := http.NewServeMux()
+	c.Register(mux, "/metrics")
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	g.Expect(recorder.Header().Get("Content-Type")).Should(gomega.HavePrefix("text/plain; version=0.0.4"))
+	body := recorder.Body.String()
+	g.Expect(body).Should(gomega.ContainSubstring("# TYPE argon2id_operations_total counter\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operations_total{operation="compare",outcome="mismatch"} 1` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operations_total{operation="hash",outcome="success"} 0` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operation_duration_seconds_bucket{operation="compare",le="0.025"} 0` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operation_duration_seconds_bucket{operation="compare",le="0.05"} 1` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operation_duration_seconds_bucket{operation="compare",le="2.5"} 2` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operation_duration_seconds_bucket{operation="compare",le="+Inf"} 2` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring(`argon2id_operation_duration_seconds_sum{operation="compare"} 2.03` + "\n"))
+	g.Expect(body).Should(gomega.ContainSubstring("argon2id_memory_in_flight_bytes 0\n"))
+}
+
+func TestPublish(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := New()
+	c.Observe(argon2id.Event{Operation: argon2id.OperationHash, Outcome: argon2id.OutcomeError})
+	c.Publish("argon2id_test")
+
+	var s Snapshot
+	g.Expect(json.NewDecoder(bytes.NewBufferString(expvar.Get("argon2id_test").String())).Decode(&s)).Should(gomega.Succeed())
+	g.Expect(s.Operations["hash"]["error"]).Should(gomega.Equal(uint64(1)))
+	g.Expect(s.Errors["error"]).Should(gomega.Equal(uint64(1)))
+}
+
+// previousObserver stands in for an observer the application set before Install
+type previousObserver struct {
+	events []argon2id.Event
+	begun  []argon2id.Params
+	ended  []argon2id.Params
+}
+
+func (o *previousObserver) Observe(e argon2id.Event) { o.events = append(o.events, e) }
+func (o *previousObserver) Begin(p argon2id.Params)  { o.begun = append(o.begun, p) }
+func (o *previousObserver) End(p argon2id.Params)    { o.ended = append(o.ended, p) }
+
+func TestInstall(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	previous := &previousObserver{}
+	argon2id.SetObserver(previous)
+
+	c := Install()
+	defer argon2id.SetObserver(nil)
+	g.Expect(Install()).Should(gomega.BeIdenticalTo(c))
+	g.Expect(argon2id.CurrentObserver()).Should(gomega.BeIdenticalTo(c))
+	g.Expect(expvar.Get("argon2id")).ShouldNot(gomega.BeNil())
+
+	argon2id.Compare("bad-hash", "test")
+	g.Expect(c.Snapshot().Operations["compare"]["invalid_hash"]).Should(gomega.Equal(uint64(1)))
+
+	// the observer set before Install still receives everything
+	p := argon2id.Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16}
+	argon2id.Key([]byte("password"), []byte("somesalt"), p)
+	g.Expect(previous.events).Should(gomega.HaveLen(2))
+	g.Expect(previous.events[0].Outcome).Should(gomega.Equal(argon2id.OutcomeInvalidHash))
+	g.Expect(previous.events[1].Operation).Should(gomega.Equal(argon2id.OperationKey))
+	g.Expect(previous.begun).Should(gomega.Equal([]argon2id.Params{p}))
+	g.Expect(previous.ended).Should(gomega.Equal([]argon2id.Params{p}))
+}
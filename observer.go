@@ -0,0 +1,174 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Operation is the kind of call an Event describes
+type Operation int
+
+// The operations that are reported to an Observer
+const (
+	OperationHash Operation = iota + 1
+	OperationCompare
+	OperationKey
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationHash:
+		return "hash"
+	case OperationCompare:
+		return "compare"
+	case OperationKey:
+		return "key"
+	}
+
+	return "unknown"
+}
+
+// Outcome classifies the error, if any, returned by an operation
+type Outcome int
+
+// The outcome classes. Each of the package's sentinel errors has its own class; anything else is OutcomeError.
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeMismatch
+	OutcomeInvalidHash
+	OutcomeInvalidComplexity
+	OutcomeInvalidVersion
+	OutcomeError
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeMismatch:
+		return "mismatch"
+	case OutcomeInvalidHash:
+		return "invalid_hash"
+	case OutcomeInvalidComplexity:
+		return "invalid_complexity"
+	case OutcomeInvalidVersion:
+		return "invalid_version"
+	}
+
+	return "error"
+}
+
+// OutcomeOf will return the outcome class of err
+func OutcomeOf(err error) Outcome {
+	switch err {
+	case nil:
+		return OutcomeSuccess
+	case ErrMismatchedHashAndPassword:
+		return OutcomeMismatch
+	case ErrInvalidHash:
+		return OutcomeInvalidHash
+	case ErrInvalidComplexity:
+		return OutcomeInvalidComplexity
+	case ErrInvalidArgon2Version:
+		return OutcomeInvalidVersion
+	}
+
+	return OutcomeError
+}
+
+// Event describes a single hash or compare call. It never contains the password, the salt or the hash.
+type Event struct {
+	Operation Operation
+	Duration  time.Duration
+
+	// Params are the parameters used to hash, or the parameters read from the hashed password. They are zero if the
+	// hashed password could not be parsed.
+	Params Params
+
+	// Format is the format of the produced or compared hash, or FormatUnknown if it was not recognised.
+	Format Format
+
+	Outcome Outcome
+}
+
+// Observer receives an Event for every hash and compare call, on the goroutine that made the call. Implementations
+// must be safe for concurrent use and should return quickly.
+type Observer interface {
+	Observe(Event)
+}
+
+// InFlightObserver is an Observer that also wants to know when the Argon2 computation for a call starts and ends,
+// for example to track the memory in use. Begin and End are only called around the computation itself, so calls that
+// fail before it (such as an unparsable hash) get neither.
+type InFlightObserver interface {
+	Observer
+	Begin(Params)
+	End(Params)
+}
+
+// ObserverFunc adapts a function to the Observer interface
+type ObserverFunc func(Event)
+
+// Observe calls f(e)
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+type observerHolder struct {
+	Observer
+}
+
+var observer atomic.Value
+
+// now is used where a "time" parameter shadows the time package
+func now() time.Time {
+	return time.Now()
+}
+
+// SetObserver will install o as the package's observer, replacing any previous one. A nil o turns observation off.
+func SetObserver(o Observer) {
+	observer.Store(observerHolder{o})
+}
+
+// CurrentObserver will return the observer installed by SetObserver, or nil if there is none
+func CurrentObserver() Observer {
+	return currentObserver()
+}
+
+func currentObserver() Observer {
+	holder, _ := observer.Load().(observerHolder)
+	return holder.Observer
+}
+
+func observe(op Operation, start time.Time, p Params, f Format, err error) {
+	o := currentObserver()
+	if o == nil {
+		return
+	}
+
+	o.Observe(Event{
+		Operation: op,
+		Duration:  time.Since(start),
+		Params:    p,
+		Format:    f,
+		Outcome:   OutcomeOf(err),
+	})
+}
@@ -0,0 +1,135 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingObserver) Observe(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestObserver(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := &recordingObserver{}
+	SetObserver(r)
+	defer SetObserver(nil)
+
+	p := Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16}
+	h, _ := HashPassword("test", p.Time, p.Memory, p.Threads, p.KeyLen)
+	Compare(h, "test")
+	Compare(h, "bad-password")
+	Compare("bad-hash", "test")
+	Compare("$argon2id99$1,65536,4$PWhquEXHn6p9NoOuQQVwHw$J2fO7RdTPYGdoBb52cyYVEMdprPkAa/2hny3n0tGNm4", "test")
+	CompareBatch(context.Background(), []Pair{{HashedPassword: h, Password: "test"}, {HashedPassword: "bad-hash"}}, BatchOptions{})
+
+	g.Expect(r.events).Should(gomega.HaveLen(7))
+
+	expected := []Event{
+		{Operation: OperationHash, Params: p, Format: FormatSynacor, Outcome: OutcomeSuccess},
+		{Operation: OperationCompare, Params: p, Format: FormatSynacor, Outcome: OutcomeSuccess},
+		{Operation: OperationCompare, Params: p, Format: FormatSynacor, Outcome: OutcomeMismatch},
+		{Operation: OperationCompare, Format: FormatUnknown, Outcome: OutcomeInvalidHash},
+		{Operation: OperationCompare, Format: FormatSynacorStd, Outcome: OutcomeInvalidVersion},
+		{Operation: OperationCompare, Params: p, Format: FormatSynacor, Outcome: OutcomeSuccess},
+		{Operation: OperationCompare, Format: FormatUnknown, Outcome: OutcomeInvalidHash},
+	}
+	for i, e := range r.events {
+		if e.Operation == OperationHash || e.Params != (Params{}) {
+			g.Expect(e.Duration).Should(gomega.BeNumerically(">", 0))
+		}
+		e.Duration = 0
+		g.Expect(e).Should(gomega.Equal(expected[i]), "event %d", i)
+	}
+
+	SetObserver(nil)
+	Compare(h, "test")
+	g.Expect(r.events).Should(gomega.HaveLen(7))
+}
+
+func TestObserverFunc(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var events []Event
+	SetObserver(ObserverFunc(func(e Event) { events = append(events, e) }))
+	defer SetObserver(nil)
+
+	Compare("bad-hash", "test")
+	g.Expect(events).Should(gomega.HaveLen(1))
+	g.Expect(events[0].Outcome.String()).Should(gomega.Equal("invalid_hash"))
+	g.Expect(events[0].Operation.String()).Should(gomega.Equal("compare"))
+	g.Expect(events[0].Format.String()).Should(gomega.Equal("unknown"))
+}
+
+func TestOutcomeOf(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(OutcomeOf(nil)).Should(gomega.Equal(OutcomeSuccess))
+	g.Expect(OutcomeOf(ErrMismatchedHashAndPassword)).Should(gomega.Equal(OutcomeMismatch))
+	g.Expect(OutcomeOf(ErrInvalidHash)).Should(gomega.Equal(OutcomeInvalidHash))
+	g.Expect(OutcomeOf(ErrInvalidComplexity)).Should(gomega.Equal(OutcomeInvalidComplexity))
+	g.Expect(OutcomeOf(ErrInvalidArgon2Version)).Should(gomega.Equal(OutcomeInvalidVersion))
+	g.Expect(OutcomeOf(errors.New("other"))).Should(gomega.Equal(OutcomeError))
+	g.Expect(OutcomeError.String()).Should(gomega.Equal("error"))
+}
+
+type inFlightObserver struct {
+	recordingObserver
+	inFlight []Params
+	begun    int
+}
+
+func (o *inFlightObserver) Begin(p Params) {
+	o.inFlight = append(o.inFlight, p)
+	o.begun++
+}
+
+func (o *inFlightObserver) End(p Params) {
+	o.inFlight = o.inFlight[:len(o.inFlight)-1]
+}
+
+func TestInFlightObserver(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	o := &inFlightObserver{}
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	h, _ := HashPassword("test", 1, 1024, 1, 16)
+	Compare(h, "test")
+	Compare("bad-hash", "test")
+
+	g.Expect(o.begun).Should(gomega.Equal(2))
+	g.Expect(o.inFlight).Should(gomega.BeEmpty())
+	g.Expect(o.events).Should(gomega.HaveLen(3))
+}
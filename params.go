@@ -0,0 +1,95 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+// Params are the Argon2 inputs, other than the password and salt, that determine a hash.
+type Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// withDefaults will replace any "0" value with its default, as set by SetDefaults
+func (p Params) withDefaults() Params {
+	return currentDefaults().override(p)
+}
+
+// override will return p with the non-"0" values of o replacing its own
+func (p Params) override(o Params) Params {
+	if o.Time != 0 {
+		p.Time = o.Time
+	}
+
+	if o.Memory != 0 {
+		p.Memory = o.Memory
+	}
+
+	if o.Threads != 0 {
+		p.Threads = o.Threads
+	}
+
+	if o.KeyLen != 0 {
+		p.KeyLen = o.KeyLen
+	}
+
+	return p
+}
+
+// DefaultParams will return the parameters used when HashPassword is given "0" values, as set by SetDefaults.
+func DefaultParams() Params {
+	return Params{}.withDefaults()
+}
+
+// GetParams will return the parameters that were used to create hashedPassword.
+func GetParams(hashedPassword string) (Params, error) {
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		return Params{}, err
+	}
+
+	return h.params(), nil
+}
+
+// NeedsRehash will return true if hashedPassword was not created with the given time, memory, threads and keyLen, or
+// if it uses a legacy format such as the standard base64 alphabet of earlier releases. As with HashPassword, a "0" value means the sane
+// default. Callers typically check this after a successful Compare and store a new hash of the password when it
+// returns true. In the rare case that the alphabet cannot be told from the string alone, only CompareNeedsRehash
+// will flag it.
+func NeedsRehash(hashedPassword string, time, memory uint32, threads uint8, keyLen uint32) (bool, error) {
+	h, err := newHashedFromHashedPassword(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	return h.needsRehash(Params{Time: time, Memory: memory, Threads: threads, KeyLen: keyLen}), nil
+}
+
+func (h *hashed) needsRehash(want Params) bool {
+	return codecsByFormat[h.format].legacy || h.params() != want.withDefaults()
+}
+
+func (h *hashed) params() Params {
+	return Params{
+		Time:    h.time,
+		Memory:  h.memory,
+		Threads: h.threads,
+		KeyLen:  uint32(len(h.hash)),
+	}
+}
@@ -0,0 +1,61 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestGetParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, _ := HashPassword("test", 2, 1024, 3, 20)
+	p, err := GetParams(h)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(p).Should(gomega.Equal(Params{Time: 2, Memory: 1024, Threads: 3, KeyLen: 20}))
+
+	_, err = GetParams("bad-hash")
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+
+	g.Expect(DefaultParams()).Should(gomega.Equal(Params{Time: 1, Memory: 64 * 1024, Threads: 4, KeyLen: 32}))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	h, _ := HashPassword("test", 1, 1024, 1, 16)
+
+	needsRehash, err := NeedsRehash(h, 1, 1024, 1, 16)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+
+	needsRehash, err = NeedsRehash(h, 1, 1024, 1, 32)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeTrue())
+
+	// "0" values compare against the defaults
+	needsRehash, err = NeedsRehash(h, 0, 0, 0, 0)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeTrue())
+
+	_, err = NeedsRehash("bad-hash", 0, 0, 0, 0)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidHash))
+}
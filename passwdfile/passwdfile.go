@@ -0,0 +1,178 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package passwdfile reads and writes the lines of Dovecot passwd-files, so that mailbox credentials can be
+// provisioned with argon2id hashes in Dovecot's {ARGON2ID} scheme.
+package passwdfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/synacor/argon2id"
+)
+
+// ErrNotFound is an error when a passwd-file has no line for the user
+var ErrNotFound = errors.New("synacor/argon2id: the user is not in the passwd-file")
+
+// Entry is one "user:password:uid:gid:gecos:home:shell:extra_fields" line. UID and GID are kept as strings because
+// Dovecot allows them to be empty or names.
+type Entry struct {
+	User     string
+	Password string
+	UID      string
+	GID      string
+	Gecos    string
+	Home     string
+	Shell    string
+
+	// Extra holds the space separated extra fields, such as "userdb_mail=maildir:~/Maildir"
+	Extra string
+}
+
+// NewEntry will return an entry for the user with hashedPassword converted to the {ARGON2ID} scheme
+func NewEntry(user, hashedPassword string) (Entry, error) {
+	password, err := argon2id.Convert(hashedPassword, argon2id.FormatDovecot)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{User: user, Password: password}
+	if err := e.validate(); err != nil {
+		return Entry{}, err
+	}
+
+	return e, nil
+}
+
+// ParseLine will parse a passwd-file line. Missing trailing fields are left empty.
+func ParseLine(line string) (Entry, error) {
+	fields := strings.SplitN(line, ":", 8)
+	if len(fields) < 2 || fields[0] == "" {
+		return Entry{}, fmt.Errorf("synacor/argon2id: %q is not a passwd-file line", line)
+	}
+
+	for len(fields) < 8 {
+		fields = append(fields, "")
+	}
+
+	return Entry{
+		User:     fields[0],
+		Password: fields[1],
+		UID:      fields[2],
+		GID:      fields[3],
+		Gecos:    fields[4],
+		Home:     fields[5],
+		Shell:    fields[6],
+		Extra:    fields[7],
+	}, nil
+}
+
+// String will return the entry as a passwd-file line, without a newline. Empty fields after the gid are left out.
+func (e Entry) String() string {
+	fields := []string{e.User, e.Password, e.UID, e.GID, e.Gecos, e.Home, e.Shell, e.Extra}
+	for len(fields) > 4 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return strings.Join(fields, ":")
+}
+
+func (e Entry) validate() error {
+	if e.User == "" {
+		return errors.New("synacor/argon2id: a passwd-file line needs a user")
+	}
+
+	for _, field := range []string{e.User, e.Password, e.UID, e.GID, e.Gecos, e.Home, e.Shell} {
+		if strings.ContainsAny(field, ":\r\n") {
+			return fmt.Errorf("synacor/argon2id: passwd-file field %q cannot contain ':' or a newline", field)
+		}
+	}
+
+	if strings.ContainsAny(e.Extra, "\r\n") {
+		return errors.New("synacor/argon2id: passwd-file extra fields cannot contain a newline")
+	}
+
+	return nil
+}
+
+// Lookup will return the entry of the user in the passwd-file, or ErrNotFound
+func Lookup(r io.Reader, user string) (Entry, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if e, ok := parseEntryLine(scanner.Text()); ok && e.User == user {
+			return e, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{}, ErrNotFound
+}
+
+// Update will copy the passwd-file in r to w with the line of e.User replaced by e, or with e appended if the user has
+// no line yet. Comments, blank lines and the lines of other users are copied unchanged.
+func Update(r io.Reader, w io.Writer, e Entry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	written := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if existing, ok := parseEntryLine(line); ok && existing.User == e.User {
+			if written {
+				// drop duplicate lines of the user, since Dovecot would only ever use the first
+				continue
+			}
+			line = e.String()
+			written = true
+		}
+
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if !written {
+		bw.WriteString(e.String())
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// parseEntryLine will parse a line that is not blank or a comment
+func parseEntryLine(line string) (Entry, bool) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Entry{}, false
+	}
+
+	e, err := ParseLine(line)
+	return e, err == nil
+}
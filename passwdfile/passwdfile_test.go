@@ -0,0 +1,107 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package passwdfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/synacor/argon2id"
+)
+
+const referencePHCHash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestParseLine(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	e, err := ParseLine("alice:{ARGON2ID}" + referencePHCHash + ":1000:1000::/home/alice::userdb_mail=maildir:~/Maildir")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(e).Should(gomega.Equal(Entry{
+		User:     "alice",
+		Password: "{ARGON2ID}" + referencePHCHash,
+		UID:      "1000",
+		GID:      "1000",
+		Home:     "/home/alice",
+		Extra:    "userdb_mail=maildir:~/Maildir",
+	}))
+	g.Expect(e.String()).Should(gomega.Equal("alice:{ARGON2ID}" + referencePHCHash + ":1000:1000::/home/alice::userdb_mail=maildir:~/Maildir"))
+	g.Expect(argon2id.Compare(e.Password, "password")).Should(gomega.Succeed())
+
+	e, err = ParseLine("bob:{PLAIN}secret")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(e).Should(gomega.Equal(Entry{User: "bob", Password: "{PLAIN}secret"}))
+	g.Expect(e.String()).Should(gomega.Equal("bob:{PLAIN}secret::"))
+
+	_, err = ParseLine("bob")
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: "bob" is not a passwd-file line`))
+}
+
+func TestNewEntry(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	e, err := NewEntry("alice", referencePHCHash)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(e.String()).Should(gomega.Equal("alice:{ARGON2ID}" + referencePHCHash + "::"))
+
+	_, err = NewEntry("alice", "bad-hash")
+	g.Expect(err).Should(gomega.Equal(argon2id.ErrInvalidHash))
+
+	_, err = NewEntry("al:ice", referencePHCHash)
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: passwd-file field "al:ice" cannot contain ':' or a newline`))
+
+	_, err = NewEntry("", referencePHCHash)
+	g.Expect(err).Should(gomega.MatchError("synacor/argon2id: a passwd-file line needs a user"))
+}
+
+func TestLookupAndUpdate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	file := "# mail users\n" +
+		"alice:{PLAIN}old:1000:1000\n" +
+		"\n" +
+		"bob:{PLAIN}secret:1001:1001\n" +
+		"alice:{PLAIN}duplicate:1000:1000\n"
+
+	e, err := Lookup(strings.NewReader(file), "bob")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(e.Password).Should(gomega.Equal("{PLAIN}secret"))
+
+	_, err = Lookup(strings.NewReader(file), "carol")
+	g.Expect(err).Should(gomega.Equal(ErrNotFound))
+
+	alice, err := NewEntry("alice", referencePHCHash)
+	g.Expect(err).Should(gomega.Succeed())
+	alice.UID, alice.GID = "1000", "1000"
+
+	buf := bytes.NewBuffer(nil)
+	g.Expect(Update(strings.NewReader(file), buf, alice)).Should(gomega.Succeed())
+	g.Expect(buf.String()).Should(gomega.Equal("# mail users\n" +
+		"alice:{ARGON2ID}" + referencePHCHash + ":1000:1000\n" +
+		"\n" +
+		"bob:{PLAIN}secret:1001:1001\n"))
+
+	carol := Entry{User: "carol", Password: "{ARGON2ID}" + referencePHCHash}
+	buf.Reset()
+	g.Expect(Update(strings.NewReader(file), buf, carol)).Should(gomega.Succeed())
+	g.Expect(buf.String()).Should(gomega.Equal(file + "carol:{ARGON2ID}" + referencePHCHash + "::\n"))
+
+	g.Expect(Update(strings.NewReader(file), buf, Entry{User: "dave", Home: "/home/a:b"})).ShouldNot(gomega.Succeed())
+}
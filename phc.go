@@ -0,0 +1,154 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// rxPHC matches the PHC string format (https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md) as
+// written by the reference implementation. A missing version means version 16 (0x10). The hash is optional so that
+// crypt(3) settings, which stop after the salt, can be read too.
+var rxPHC = regexp.MustCompile(`^\$argon2id\$(?:v=([0-9]{1,4})\$)?m=([0-9]{1,10}),t=([0-9]{1,10}),p=([0-9]{1,3})\$([+/a-zA-Z0-9]+)(?:\$([+/a-zA-Z0-9]*))?$`)
+
+const phcPrefix = "$argon2id$"
+
+func matchPHC(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, phcPrefix)
+}
+
+func detectPHC(hashedPassword string) Format {
+	if match := rxPHC.FindStringSubmatch(hashedPassword); match != nil && match[6] != "" {
+		return FormatPHC
+	}
+
+	return FormatUnknown
+}
+
+func newHashedFromPHC(hashedPassword string) (*hashed, error) {
+	match := rxPHC.FindStringSubmatch(hashedPassword)
+	if match == nil || match[6] == "" {
+		return nil, ErrInvalidHash
+	}
+
+	return newHashedFromPHCMatch(match)
+}
+
+// newHashedFromPHCMatch will decode the submatches of rxPHC. The hash is left empty if there is none.
+func newHashedFromPHCMatch(match []string) (*hashed, error) {
+	// as with the other format, the pattern ensures these are numeric and small enough to convert
+	version := 0x10
+	if match[1] != "" {
+		version, _ = strconv.Atoi(match[1])
+	}
+	memory, _ := strconv.Atoi(match[2])
+	time, _ := strconv.Atoi(match[3])
+	threads, _ := strconv.Atoi(match[4])
+	salt, hash := match[5], match[6]
+
+	if version != argon2.Version {
+		return nil, ErrInvalidArgon2Version
+	}
+
+	rawHash, err := stdEncoding.DecodeString(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	rawSalt, err := stdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validComplexity(uint64(time), uint64(memory), uint64(threads)) {
+		return nil, ErrInvalidComplexity
+	}
+
+	return &hashed{
+		format:  FormatPHC,
+		time:    uint32(time),
+		memory:  uint32(memory),
+		threads: uint8(threads),
+		hash:    rawHash,
+		salt:    rawSalt,
+	}, nil
+}
+
+func encodePHC(h *hashed) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", phcPrefix, argon2.Version, h.memory, h.time, h.threads, stdEncoding.EncodeToString(h.salt), stdEncoding.EncodeToString(h.hash))
+}
+
+// prefixedPHC is a format that is FormatPHC behind a scheme prefix, as used by directories, mail servers and
+// frameworks that store hashes of several algorithms side by side
+type prefixedPHC struct {
+	format   Format
+	prefix   string
+	foldCase bool
+}
+
+func (p prefixedPHC) match(hashedPassword string) bool {
+	if len(hashedPassword) < len(p.prefix) {
+		return false
+	}
+
+	if p.foldCase {
+		return strings.EqualFold(hashedPassword[:len(p.prefix)], p.prefix)
+	}
+
+	return hashedPassword[:len(p.prefix)] == p.prefix
+}
+
+func (p prefixedPHC) detect(hashedPassword string) Format {
+	if p.match(hashedPassword) && detectPHC(hashedPassword[len(p.prefix):]) == FormatPHC {
+		return p.format
+	}
+
+	return FormatUnknown
+}
+
+func (p prefixedPHC) decode(hashedPassword string) (*hashed, error) {
+	if !p.match(hashedPassword) {
+		return nil, ErrInvalidHash
+	}
+
+	h, err := newHashedFromPHC(hashedPassword[len(p.prefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	h.format = p.format
+	if prefix := hashedPassword[:len(p.prefix)]; prefix != p.prefix {
+		h.prefix = prefix
+	}
+	return h, nil
+}
+
+// encode will write the prefix that was read, if any, so that a hash is not changed by being decoded and encoded
+func (p prefixedPHC) encode(h *hashed) string {
+	if h.prefix != "" {
+		return h.prefix + encodePHC(h)
+	}
+
+	return p.prefix + encodePHC(h)
+}
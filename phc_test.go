@@ -0,0 +1,59 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// from the reference implementation's test suite (https://github.com/P-H-C/phc-winner-argon2/blob/master/src/test.c)
+const referencePHCHash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestComparePHC(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(IsHashedPassword(referencePHCHash)).Should(gomega.BeTrue())
+	g.Expect(DetectFormat(referencePHCHash)).Should(gomega.Equal(FormatPHC))
+	g.Expect(Compare(referencePHCHash, "password")).Should(gomega.Succeed())
+	g.Expect(Compare(referencePHCHash, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+	p, err := GetParams(referencePHCHash)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(p).Should(gomega.Equal(Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32}))
+
+	// PHC is not a legacy format, so only the parameters matter
+	needsRehash, err := NeedsRehash(referencePHCHash, 2, 65536, 1, 32)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(needsRehash).Should(gomega.BeFalse())
+}
+
+func TestComparePHCFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(Compare("$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ", "password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(Compare("$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidArgon2Version))
+	g.Expect(Compare("$argon2id$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidArgon2Version), "no version means version 16")
+	g.Expect(Compare("$argon2id$v=19$m=65536,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidComplexity))
+	g.Expect(Compare("$argon2id$v=19$m=65536,t=2,p=256$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidComplexity))
+	g.Expect(Compare("$argon2id$v=19$m=65536,t=2,p=1$a$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.MatchError(base64.CorruptInputError(0)))
+	g.Expect(Compare("$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$C.FhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")).Should(gomega.Equal(ErrInvalidHash))
+}
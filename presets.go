@@ -0,0 +1,95 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Parameter presets from published guidance. Each uses a 32 byte hash, as recommended by RFC 9106.
+var (
+	// RFC9106FirstRecommended is the first recommended option of RFC 9106 section 4: t=1, 2 GiB of memory and 4 lanes.
+	// It needs a lot of memory, so it suits dedicated hashing servers rather than every login.
+	RFC9106FirstRecommended = Params{Time: 1, Memory: 2 * 1024 * 1024, Threads: 4, KeyLen: 32}
+
+	// RFC9106SecondRecommended is the second recommended option of RFC 9106 section 4, for memory-constrained
+	// environments: t=3, 64 MiB of memory and 4 lanes
+	RFC9106SecondRecommended = Params{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32}
+
+	// OWASPMinimums are the equivalent minimum configurations of the OWASP Password Storage Cheat Sheet, trading
+	// memory for passes: 46 MiB and t=1, 19 MiB and t=2, 12 MiB and t=3, 9 MiB and t=4, and 7 MiB and t=5, each with
+	// a single lane
+	OWASPMinimums = [...]Params{
+		{Time: 1, Memory: 46 * 1024, Threads: 1, KeyLen: 32},
+		{Time: 2, Memory: 19 * 1024, Threads: 1, KeyLen: 32},
+		{Time: 3, Memory: 12 * 1024, Threads: 1, KeyLen: 32},
+		{Time: 4, Memory: 9 * 1024, Threads: 1, KeyLen: 32},
+		{Time: 5, Memory: 7 * 1024, Threads: 1, KeyLen: 32},
+	}
+
+	// Interactive is for logins that should finish well within a second; it equals LibsodiumInteractive
+	Interactive = LibsodiumInteractive
+
+	// Moderate is for less frequent operations where a slower hash is acceptable; it equals LibsodiumModerate
+	Moderate = LibsodiumModerate
+
+	// Sensitive is for rare operations protecting high-value secrets, taking several seconds; it equals
+	// LibsodiumSensitive
+	Sensitive = LibsodiumSensitive
+)
+
+// presets are the presets by the names that ParsePreset accepts. They are copies taken when the package is initialized,
+// so assigning to the exported variables does not change what ParsePreset returns.
+var presets = map[string]Params{
+	"rfc9106-first":         RFC9106FirstRecommended,
+	"rfc9106-second":        RFC9106SecondRecommended,
+	"owasp-1":               OWASPMinimums[0],
+	"owasp-2":               OWASPMinimums[1],
+	"owasp-3":               OWASPMinimums[2],
+	"owasp-4":               OWASPMinimums[3],
+	"owasp-5":               OWASPMinimums[4],
+	"interactive":           Interactive,
+	"moderate":              Moderate,
+	"sensitive":             Sensitive,
+	"libsodium-interactive": LibsodiumInteractive,
+	"libsodium-moderate":    LibsodiumModerate,
+	"libsodium-sensitive":   LibsodiumSensitive,
+}
+
+// Presets will return the names of the presets, sorted
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ParsePreset will return the parameters of the preset with the given name, as returned by Presets()
+func ParsePreset(name string) (Params, error) {
+	p, ok := presets[name]
+	if !ok {
+		return Params{}, fmt.Errorf("synacor/argon2id: unknown preset %q", name)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,75 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestPresets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(RFC9106FirstRecommended).Should(gomega.Equal(Params{Time: 1, Memory: 2097152, Threads: 4, KeyLen: 32}))
+	g.Expect(RFC9106SecondRecommended).Should(gomega.Equal(Params{Time: 3, Memory: 65536, Threads: 4, KeyLen: 32}))
+	g.Expect(OWASPMinimums[0]).Should(gomega.Equal(Params{Time: 1, Memory: 47104, Threads: 1, KeyLen: 32}))
+	g.Expect(OWASPMinimums[4]).Should(gomega.Equal(Params{Time: 5, Memory: 7168, Threads: 1, KeyLen: 32}))
+	g.Expect(Interactive).Should(gomega.Equal(LibsodiumInteractive))
+
+	g.Expect(Presets()).Should(gomega.Equal([]string{
+		"interactive",
+		"libsodium-interactive",
+		"libsodium-moderate",
+		"libsodium-sensitive",
+		"moderate",
+		"owasp-1",
+		"owasp-2",
+		"owasp-3",
+		"owasp-4",
+		"owasp-5",
+		"rfc9106-first",
+		"rfc9106-second",
+		"sensitive",
+	}))
+
+	for _, name := range Presets() {
+		p, err := ParsePreset(name)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(p.withDefaults()).Should(gomega.Equal(p), name)
+	}
+
+	g.Expect(ParsePreset("owasp-3")).Should(gomega.Equal(OWASPMinimums[2]))
+	g.Expect(ParsePreset("rfc9106-second")).Should(gomega.Equal(RFC9106SecondRecommended))
+
+	_, err := ParsePreset("fast")
+	g.Expect(err).Should(gomega.MatchError(`synacor/argon2id: unknown preset "fast"`))
+}
+
+func TestPresetsCannotBeChanged(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	saved, savedOWASP := Interactive, OWASPMinimums
+	defer func() { Interactive, OWASPMinimums = saved, savedOWASP }()
+
+	Interactive.Memory = 8
+	OWASPMinimums[0].Time = 100
+	g.Expect(ParsePreset("interactive")).Should(gomega.Equal(LibsodiumInteractive))
+	g.Expect(ParsePreset("owasp-1")).Should(gomega.Equal(Params{Time: 1, Memory: 46 * 1024, Threads: 1, KeyLen: 32}))
+}
@@ -0,0 +1,59 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import "github.com/synacor/argon2id/internal/argon2"
+
+// Progress describes how far an Argon2 computation has got. Argon2 makes Params.Time passes over its memory, each in
+// Slices slices, and a Progress is reported once each slice is done.
+type Progress struct {
+	Params Params
+
+	// Pass and Slice count from "1", so the computation is finished when Pass == Params.Time and Slice == Slices
+	Pass  uint32
+	Slice uint32
+}
+
+// Slices is the number of slices in each pass over the memory
+const Slices = argon2.SlicesPerPass
+
+// Done returns the number of slices finished and the total number of slices
+func (p Progress) Done() (done, total uint32) {
+	return (p.Pass-1)*Slices + p.Slice, p.Params.Time * Slices
+}
+
+// Fraction returns the share of the computation that is finished, from 0 to 1
+func (p Progress) Fraction() float64 {
+	done, total := p.Done()
+	return float64(done) / float64(total)
+}
+
+// ProgressFunc receives a Progress after each slice of a computation, on the goroutine that made the call. It slows
+// the computation down if it does not return quickly.
+type ProgressFunc func(Progress)
+
+func (fn ProgressFunc) slices(p Params) func(pass, slice uint32) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(pass, slice uint32) {
+		fn(Progress{Params: p, Pass: pass + 1, Slice: slice + 1})
+	}
+}
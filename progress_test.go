@@ -0,0 +1,68 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestProgress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	p := Progress{Params: Params{Time: 3}, Pass: 2, Slice: 2}
+	done, total := p.Done()
+	g.Expect(done).Should(gomega.Equal(uint32(6)))
+	g.Expect(total).Should(gomega.Equal(uint32(12)))
+	g.Expect(p.Fraction()).Should(gomega.Equal(0.5))
+}
+
+func TestKeyWithProgress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var reported []Progress
+	p := Params{Time: 2, Memory: 65536, Threads: 1, KeyLen: 32}
+	key := KeyWithProgress([]byte("password"), []byte("somesalt"), p, func(progress Progress) {
+		reported = append(reported, progress)
+	})
+	g.Expect(hex.EncodeToString(key)).Should(gomega.Equal("09316115d5cf24ed5a15a31a3ba326e5cf32edc24702987c02b6566f61913cf7"))
+
+	g.Expect(reported).Should(gomega.HaveLen(2 * Slices))
+	g.Expect(reported[0]).Should(gomega.Equal(Progress{Params: p, Pass: 1, Slice: 1}))
+	g.Expect(reported[len(reported)-1]).Should(gomega.Equal(Progress{Params: p, Pass: 2, Slice: Slices}))
+	g.Expect(reported[len(reported)-1].Fraction()).Should(gomega.Equal(1.0))
+}
+
+func TestHasherProgress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	SetComputeOptions(ComputeOptions{Workers: 1})
+	defer SetComputeOptions(ComputeOptions{})
+
+	var fractions []float64
+	hr := Hasher{Params: Params{Time: 1, Memory: 1024, Threads: 2}, Progress: func(progress Progress) {
+		fractions = append(fractions, progress.Fraction())
+	}}
+	hashedPassword, err := hr.Hash("password")
+	g.Expect(err).ShouldNot(gomega.HaveOccurred())
+	g.Expect(Compare(hashedPassword, "password")).Should(gomega.Succeed())
+	g.Expect(fractions).Should(gomega.Equal([]float64{0.25, 0.5, 0.75, 1}))
+}
@@ -0,0 +1,100 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package securebuf
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// memory is a mapping of a guard page, the data pages and another guard page. The data is placed at the end of the
+// data pages, so that reading or writing past it faults on the trailing guard page.
+type memory struct {
+	mapping []byte
+	inner   []byte
+}
+
+func allocate(size int) (memory, []byte, error) {
+	pageSize := os.Getpagesize()
+	dataPages := (size + pageSize - 1) / pageSize * pageSize
+
+	mapping, err := unix.Mmap(-1, 0, pageSize+dataPages+pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return memory{}, nil, os.NewSyscallError("mmap", err)
+	}
+
+	m := memory{mapping: mapping, inner: mapping[pageSize : pageSize+dataPages]}
+	fail := func(name string, err error) (memory, []byte, error) {
+		unix.Munmap(mapping)
+		return memory{}, nil, os.NewSyscallError(name, err)
+	}
+
+	if err := unix.Mprotect(mapping[:pageSize], unix.PROT_NONE); err != nil {
+		return fail("mprotect", err)
+	}
+	if err := unix.Mprotect(mapping[pageSize+dataPages:], unix.PROT_NONE); err != nil {
+		return fail("mprotect", err)
+	}
+
+	// this fails if RLIMIT_MEMLOCK is too low, which is better reported than ignored
+	if err := unix.Mlock(m.inner); err != nil {
+		return fail("mlock", err)
+	}
+	if err := unix.Madvise(m.inner, unix.MADV_DONTDUMP); err != nil {
+		unix.Munlock(m.inner)
+		return fail("madvise", err)
+	}
+
+	return m, m.inner[dataPages-size:], nil
+}
+
+func (m memory) protect(readOnly bool) error {
+	prot := unix.PROT_READ | unix.PROT_WRITE
+	if readOnly {
+		prot = unix.PROT_READ
+	}
+
+	if err := unix.Mprotect(m.inner, prot); err != nil {
+		return os.NewSyscallError("mprotect", err)
+	}
+
+	return nil
+}
+
+func (m memory) locked() bool {
+	return true
+}
+
+func (m memory) destroy() error {
+	if err := m.protect(false); err != nil {
+		return err
+	}
+
+	for i := range m.inner {
+		m.inner[i] = 0
+	}
+
+	unix.Munlock(m.inner)
+	if err := unix.Munmap(m.mapping); err != nil {
+		return os.NewSyscallError("munmap", err)
+	}
+
+	return nil
+}
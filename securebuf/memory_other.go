@@ -0,0 +1,48 @@
+//go:build !linux
+// +build !linux
+
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package securebuf
+
+// memory is ordinary heap memory on systems without the Linux implementation. It is only wiped on Close.
+type memory struct {
+	data []byte
+}
+
+func allocate(size int) (memory, []byte, error) {
+	data := make([]byte, size)
+	return memory{data: data}, data, nil
+}
+
+func (m memory) protect(readOnly bool) error {
+	return nil
+}
+
+func (m memory) locked() bool {
+	return false
+}
+
+func (m memory) destroy() error {
+	for i := range m.data {
+		m.data[i] = 0
+	}
+
+	return nil
+}
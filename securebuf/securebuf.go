@@ -0,0 +1,182 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package securebuf holds passwords and other secrets outside the Go heap. On Linux, a Buffer is locked into memory
+// so it is never written to swap, excluded from core dumps, placed between guard pages that fault on overruns, and
+// read-only once sealed. Closing it wipes and releases the memory. On other systems it falls back to ordinary memory
+// that is still wiped on Close.
+//
+// A Buffer is not closed by the garbage collector, because a slice returned by Bytes does not keep the Buffer
+// reachable; it must be closed explicitly. The argon2id package's Buffer-based functions, such as CompareBuffer and
+// Hasher.HashBuffer, use the secret through Use, so a concurrent Close waits for them. Argon2 itself still copies the
+// password into its own working state for the length of the computation.
+package securebuf
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrInvalidSize is an error when a Buffer of zero or negative size is requested
+var ErrInvalidSize = errors.New("synacor/argon2id: a secure buffer must hold at least one byte")
+
+// ErrClosed is an error when a Buffer is used after Close
+var ErrClosed = errors.New("synacor/argon2id: the secure buffer is closed")
+
+// ErrSealed is an error when a sealed Buffer is written to
+var ErrSealed = errors.New("synacor/argon2id: the secure buffer is sealed")
+
+// Buffer is a fixed-size secret. It is written once, through Write or FromBytes, then sealed and read with Bytes.
+type Buffer struct {
+	mu     sync.RWMutex
+	mem    memory
+	data   []byte
+	n      int
+	sealed bool
+}
+
+// New will return an empty Buffer with room for size bytes
+func New(size int) (*Buffer, error) {
+	if size <= 0 {
+		return nil, ErrInvalidSize
+	}
+
+	mem, data, err := allocate(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Buffer{mem: mem, data: data}, nil
+}
+
+// FromBytes will return a sealed Buffer holding a copy of secret, and wipe secret
+func FromBytes(secret []byte) (*Buffer, error) {
+	b, err := New(len(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	b.Write(secret)
+	for i := range secret {
+		secret[i] = 0
+	}
+
+	if err := b.Seal(); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Write will append p to the secret, returning io.ErrShortWrite if it does not all fit
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.data == nil {
+		return 0, ErrClosed
+	}
+	if b.sealed {
+		return 0, ErrSealed
+	}
+
+	n := copy(b.data[b.n:], p)
+	b.n += n
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+
+	return n, nil
+}
+
+// Seal will make the Buffer read-only. Writing to the slice returned by Bytes after this faults on Linux.
+func (b *Buffer) Seal() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.data == nil {
+		return ErrClosed
+	}
+	if b.sealed {
+		return nil
+	}
+
+	if err := b.mem.protect(true); err != nil {
+		return err
+	}
+
+	b.sealed = true
+	return nil
+}
+
+// Bytes will return the secret as written so far. The slice is only valid until Close, and must not be modified.
+// It is nil after Close. Prefer Use, which stops the Buffer from being closed while the slice is in use.
+func (b *Buffer) Bytes() []byte {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.data == nil {
+		return nil
+	}
+
+	return b.data[:b.n:b.n]
+}
+
+// Use will call fn with the secret as written so far and return its error, or return ErrClosed without calling fn if
+// the Buffer is closed. Calls to Use from several goroutines run at the same time, and Close waits for all of them to
+// return. fn must not keep the slice, modify it or call other methods of the Buffer.
+func (b *Buffer) Use(fn func(secret []byte) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.data == nil {
+		return ErrClosed
+	}
+
+	return fn(b.data[:b.n:b.n])
+}
+
+// Len will return the number of bytes written
+func (b *Buffer) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.n
+}
+
+// Locked will report whether the secret is held in locked memory rather than the fallback
+func (b *Buffer) Locked() bool {
+	return b.mem.locked()
+}
+
+// Close will wipe and release the memory. It is safe to call more than once, and must be called; the garbage collector
+// does not release a Buffer.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.data == nil {
+		return nil
+	}
+
+	err := b.mem.destroy()
+	b.data, b.n = nil, 0
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package securebuf
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestBuffer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	b, err := New(11)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(b.Locked()).Should(gomega.Equal(runtime.GOOS == "linux"))
+
+	g.Expect(b.Write([]byte("my-"))).Should(gomega.Equal(3))
+	g.Expect(b.Write([]byte("password"))).Should(gomega.Equal(8))
+	g.Expect(b.Len()).Should(gomega.Equal(11))
+	g.Expect(b.Bytes()).Should(gomega.Equal([]byte("my-password")))
+
+	n, err := b.Write([]byte("!"))
+	g.Expect(n).Should(gomega.BeZero())
+	g.Expect(err).Should(gomega.Equal(io.ErrShortWrite))
+
+	g.Expect(b.Seal()).Should(gomega.Succeed())
+	g.Expect(b.Seal()).Should(gomega.Succeed())
+	_, err = b.Write([]byte("!"))
+	g.Expect(err).Should(gomega.Equal(ErrSealed))
+
+	var used []byte
+	g.Expect(b.Use(func(secret []byte) error {
+		used = append(used, secret...)
+		return nil
+	})).Should(gomega.Succeed())
+	g.Expect(used).Should(gomega.Equal([]byte("my-password")))
+
+	g.Expect(b.Close()).Should(gomega.Succeed())
+	g.Expect(b.Close()).Should(gomega.Succeed())
+	g.Expect(b.Bytes()).Should(gomega.BeNil())
+	g.Expect(b.Len()).Should(gomega.BeZero())
+	g.Expect(b.Seal()).Should(gomega.Equal(ErrClosed))
+	_, err = b.Write([]byte("!"))
+	g.Expect(err).Should(gomega.Equal(ErrClosed))
+	g.Expect(b.Use(func([]byte) error { return nil })).Should(gomega.Equal(ErrClosed))
+}
+
+func TestBufferConcurrentUse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	b, err := FromBytes([]byte("my-password"))
+	g.Expect(err).Should(gomega.Succeed())
+
+	// each call waits inside fn until the other one has started, which only happens if they overlap
+	inside := make(chan struct{}, 2)
+	release := make(chan struct{})
+	use := func() error {
+		return b.Use(func([]byte) error {
+			inside <- struct{}{}
+			select {
+			case <-release:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("the other call did not start")
+			}
+		})
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- use() }()
+	go func() { errs <- use() }()
+	<-inside
+	<-inside
+
+	// Close waits for both of them
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close() }()
+	g.Consistently(closed, 50*time.Millisecond).ShouldNot(gomega.Receive())
+
+	close(release)
+	g.Expect(<-errs).Should(gomega.Succeed())
+	g.Expect(<-errs).Should(gomega.Succeed())
+	g.Expect(<-closed).Should(gomega.Succeed())
+}
+
+func TestBufferNotClosedByGC(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// only the slice is kept, as when it is passed straight to a []byte API
+	data := func() []byte {
+		b, err := FromBytes([]byte("my-password"))
+		g.Expect(err).Should(gomega.Succeed())
+		return b.Bytes()
+	}()
+
+	runtime.GC()
+	runtime.GC()
+	g.Expect(data).Should(gomega.Equal([]byte("my-password")))
+}
+
+func TestFromBytes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	secret := []byte("pepper-key")
+	b, err := FromBytes(secret)
+	g.Expect(err).Should(gomega.Succeed())
+	defer b.Close()
+
+	g.Expect(secret).Should(gomega.Equal(make([]byte, len("pepper-key"))))
+	g.Expect(b.Bytes()).Should(gomega.Equal([]byte("pepper-key")))
+	_, err = b.Write([]byte("!"))
+	g.Expect(err).Should(gomega.Equal(ErrSealed))
+
+	_, err = FromBytes(nil)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidSize))
+	_, err = New(0)
+	g.Expect(err).Should(gomega.Equal(ErrInvalidSize))
+}
+
+func TestLargeBuffer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// more than one page, so the data spans pages and ends against the trailing guard page
+	b, err := New(5000)
+	g.Expect(err).Should(gomega.Succeed())
+	defer b.Close()
+
+	data := make([]byte, 5000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	g.Expect(b.Write(data)).Should(gomega.Equal(5000))
+	g.Expect(b.Bytes()).Should(gomega.Equal(data))
+}
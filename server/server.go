@@ -0,0 +1,328 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package server implements the argon2id hashing daemon. It accepts connections on a Unix socket or a loopback TCP
+// address and performs hash, compare and needs-rehash operations on behalf of the client package, so that the
+// memory and CPU cost of Argon2 can be kept on dedicated nodes.
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"runtime"
+	"sync"
+
+	"github.com/synacor/argon2id"
+	"github.com/synacor/argon2id/internal/protocol"
+	"github.com/synacor/argon2id/internal/semaphore"
+)
+
+// ErrServerClosed is returned by Serve after Close has been called
+var ErrServerClosed = errors.New("synacor/argon2id: server closed")
+
+// ErrNotLoopback is returned by Listen when a TCP address is not on the loopback interface
+var ErrNotLoopback = errors.New("synacor/argon2id: tcp addresses must be on the loopback interface")
+
+// ErrOverMemoryBudget is returned for an operation that needs more Argon2 memory than the whole MemoryBudget
+var ErrOverMemoryBudget = errors.New("synacor/argon2id: the operation needs more memory than the memory budget")
+
+// DefaultMemoryBudget is the MemoryBudget used if it is "0". It leaves room for four hashes using the package's
+// default memory.
+const DefaultMemoryBudget = 4 * 64 * 1024
+
+// Server handles client connections. The zero value is ready to use.
+type Server struct {
+	// MaxConcurrent is the maximum number of operations that run at once, across all connections. If "0",
+	// runtime.NumCPU() is used.
+	MaxConcurrent int
+
+	// MemoryBudget is the maximum amount of Argon2 memory, in KiB, that may be in use at once. An operation that needs
+	// more than the whole budget is rejected with ErrOverMemoryBudget. If "0", 256 MiB is used.
+	MemoryBudget uint64
+
+	// ErrorLog is used to report connection errors. If nil, the log package's standard logger is used.
+	ErrorLog *log.Logger
+
+	once         sync.Once
+	ctx          context.Context
+	cancel       context.CancelFunc
+	workers      *semaphore.Weighted
+	budget       *semaphore.Weighted
+	memoryBudget uint64
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// Listen will listen on an address of the form "unix:<path>" or "tcp:<host>:<port>". TCP addresses must resolve to
+// a loopback address because passwords are sent unencrypted.
+func Listen(address string) (net.Listener, error) {
+	network, addr, err := protocol.SplitAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if network != "unix" {
+		tcpAddr, err := net.ResolveTCPAddr(network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if tcpAddr.IP == nil || !tcpAddr.IP.IsLoopback() {
+			return nil, ErrNotLoopback
+		}
+	}
+
+	return net.Listen(network, addr)
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		maxConcurrent := s.MaxConcurrent
+		if maxConcurrent <= 0 {
+			maxConcurrent = runtime.NumCPU()
+		}
+
+		memoryBudget := s.MemoryBudget
+		if memoryBudget == 0 {
+			memoryBudget = DefaultMemoryBudget
+		}
+
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.workers = semaphore.New(uint64(maxConcurrent))
+		s.budget = semaphore.New(memoryBudget)
+		s.memoryBudget = memoryBudget
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[net.Conn]struct{})
+	})
+}
+
+// Serve will accept connections on l until Close is called, at which point ErrServerClosed is returned.
+func (s *Server) Serve(l net.Listener) error {
+	s.init()
+
+	if !s.track(l, true) {
+		return ErrServerClosed
+	}
+	defer s.track(l, false)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+
+			return err
+		}
+
+		if !s.trackConn(conn, true) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go func() {
+			defer s.wg.Done()
+			defer s.trackConn(conn, false)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close will stop all listeners, close all connections and wait for running operations to finish.
+func (s *Server) Close() error {
+	s.init()
+	s.cancel()
+
+	s.mu.Lock()
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) track(l net.Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.listeners, l)
+		return true
+	}
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) trackConn(conn net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.conns, conn)
+		return true
+	}
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), protocol.MaxMessageSize)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req protocol.Request
+		var resp protocol.Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.SetError(fmt.Errorf("synacor/argon2id: malformed request: %v", err))
+		} else {
+			resp = s.handle(req)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			s.logf("synacor/argon2id: could not write response: %v", err)
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && err != io.EOF && s.ctx.Err() == nil {
+		s.logf("synacor/argon2id: could not read request: %v", err)
+	}
+}
+
+func (s *Server) handle(req protocol.Request) (resp protocol.Response) {
+	defer wipe(req.Password)
+
+	var memory uint32
+	switch req.Op {
+	case protocol.OpHash:
+		memory = req.Memory
+		if memory == 0 {
+			memory = argon2id.DefaultParams().Memory
+		}
+
+	case protocol.OpCompare:
+		params, err := argon2id.GetParams(req.HashedPassword)
+		if err != nil {
+			resp.SetError(err)
+			return
+		}
+		memory = params.Memory
+
+	case protocol.OpNeedsRehash:
+		needsRehash, err := argon2id.NeedsRehash(req.HashedPassword, req.Time, req.Memory, req.Threads, req.KeyLen)
+		if err != nil {
+			resp.SetError(err)
+		}
+		resp.NeedsRehash = needsRehash
+		return
+
+	default:
+		resp.SetError(fmt.Errorf("synacor/argon2id: unknown operation %q", req.Op))
+		return
+	}
+
+	release, err := s.acquire(uint64(memory))
+	if err != nil {
+		resp.SetError(err)
+		return
+	}
+	defer release()
+
+	if req.Op == protocol.OpHash {
+		hashedPassword, err := argon2id.HashPasswordBytes(req.Password, req.Time, req.Memory, req.Threads, req.KeyLen)
+		if err != nil {
+			resp.SetError(err)
+		}
+		resp.HashedPassword = hashedPassword
+		return
+	}
+
+	if err := argon2id.CompareBytes(req.HashedPassword, req.Password); err != nil {
+		resp.SetError(err)
+	}
+	return
+}
+
+// acquire will wait for a worker and for memory KiB of the memory budget. An operation that could never fit in the
+// budget is refused, since running it would use more memory than the daemon is allowed.
+func (s *Server) acquire(memory uint64) (release func(), err error) {
+	if memory > s.memoryBudget {
+		return nil, ErrOverMemoryBudget
+	}
+
+	if err := s.workers.Acquire(s.ctx, 1); err != nil {
+		return nil, ErrServerClosed
+	}
+
+	if err := s.budget.Acquire(s.ctx, memory); err != nil {
+		s.workers.Release(1)
+		return nil, ErrServerClosed
+	}
+
+	return func() {
+		s.budget.Release(memory)
+		s.workers.Release(1)
+	}, nil
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+		return
+	}
+
+	log.Printf(format, args...)
+}
+
+// wipe will overwrite b with zeros once the request no longer needs it
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
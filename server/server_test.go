@@ -0,0 +1,110 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func startServer(t *testing.T, s *Server) (address string, stop func()) {
+	dir, err := ioutil.TempDir("", "argon2id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address = "unix:" + filepath.Join(dir, "argon2id.sock")
+	l, err := Listen(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+
+	return address, func() {
+		s.Close()
+		if err := <-done; err != ErrServerClosed {
+			t.Errorf("Serve returned %v", err)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestServerRequests(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	address, stop := startServer(t, &Server{MaxConcurrent: 2, MemoryBudget: 2048})
+	defer stop()
+
+	conn, err := net.Dial("unix", address[len("unix:"):])
+	g.Expect(err).Should(gomega.Succeed())
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	send := func(line string) string {
+		fmt.Fprintln(conn, line)
+		resp, err := reader.ReadString('\n')
+		g.Expect(err).Should(gomega.Succeed())
+		return resp
+	}
+
+	g.Expect(send(`{"op":"compare","hashed_password":"bad-hash","password":"eA=="}`)).Should(gomega.Equal(`{"error_code":"invalid_hash","error":"synacor/argon2id: the hashed password is not a valid hash"}` + "\n"))
+	g.Expect(send(`{"op":"hash","password":"eA==","time":1,"memory":1024,"threads":1}`)).Should(gomega.MatchRegexp(`^\{"hashed_password":"\$argon2id19\$1,1024,1\$`))
+
+	// operations that need more than the whole memory budget are refused rather than run
+	g.Expect(send(`{"op":"hash","password":"eA==","time":1,"memory":4294967295,"threads":1}`)).Should(gomega.Equal(`{"error_code":"error","error":"synacor/argon2id: the operation needs more memory than the memory budget"}` + "\n"))
+	g.Expect(send(`{"op":"compare","hashed_password":"$argon2id19$1,4096,1$fjSIS8wLOEZRF/9ceB3Ct.$YCdi8.UQsEGFBsAwVGH/U5lwlvHWLbUl7MzSXwFJ7Oy","password":"eA=="}`)).Should(gomega.ContainSubstring(`more memory than the memory budget`))
+
+	g.Expect(send(`{"op":"bogus"}`)).Should(gomega.ContainSubstring(`"error_code":"error"`))
+	g.Expect(send(`not json`)).Should(gomega.ContainSubstring(`malformed request`))
+}
+
+func TestListen(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := Listen("tcp:0.0.0.0:0")
+	g.Expect(err).Should(gomega.Equal(ErrNotLoopback))
+
+	_, err = Listen("bogus")
+	g.Expect(err).ShouldNot(gomega.Succeed())
+
+	l, err := Listen("tcp:127.0.0.1:0")
+	g.Expect(err).Should(gomega.Succeed())
+	l.Close()
+}
+
+func TestServeAfterClose(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var s Server
+	g.Expect(s.Close()).Should(gomega.Succeed())
+
+	l, err := Listen("tcp:127.0.0.1:0")
+	g.Expect(err).Should(gomega.Succeed())
+	defer l.Close()
+	g.Expect(s.Serve(l)).Should(gomega.Equal(ErrServerClosed))
+}
@@ -0,0 +1,28 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+// Spring Security's DelegatingPasswordEncoder stores the id of the encoder in front of the PHC string written by
+// Argon2PasswordEncoder. Since 5.8 there is a second id for the newer default parameters. Both verify the same way,
+// but they are separate formats so that a hash keeps the id it was written with. Ids are case sensitive, so the
+// "{ARGON2}" of OpenLDAP is not confused with them.
+var (
+	springScheme    = prefixedPHC{format: FormatSpring, prefix: "{argon2}"}
+	springV58Scheme = prefixedPHC{format: FormatSpringV58, prefix: "{argon2@SpringSecurity_v5_8}"}
+)
@@ -0,0 +1,69 @@
+/*
+argon2id - Go password hashing utility using Argon2
+Copyright (C) 2019 Synacor, Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package argon2id
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCompareSpring(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for prefix, format := range map[string]Format{"{argon2}": FormatSpring, "{argon2@SpringSecurity_v5_8}": FormatSpringV58} {
+		h := prefix + referencePHCHash
+		g.Expect(DetectFormat(h)).Should(gomega.Equal(format))
+		g.Expect(Compare(h, "password")).Should(gomega.Succeed())
+		g.Expect(Compare(h, "bad-password")).Should(gomega.Equal(ErrMismatchedHashAndPassword))
+
+		converted, err := Convert(h, FormatPHC)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(converted).Should(gomega.Equal(referencePHCHash))
+
+		// the encoder id is kept
+		converted, err = Convert(h, format)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(converted).Should(gomega.Equal(h))
+
+		data, err := Hash(h).MarshalBinary()
+		g.Expect(err).Should(gomega.Succeed())
+		var back Hash
+		g.Expect(back.UnmarshalBinary(data)).Should(gomega.Succeed())
+		g.Expect(back).Should(gomega.Equal(Hash(h)))
+	}
+
+	converted, err := Convert("{argon2@SpringSecurity_v5_8}"+referencePHCHash, FormatSpring)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(converted).Should(gomega.Equal("{argon2}" + referencePHCHash))
+
+	// Argon2PasswordEncoder without DelegatingPasswordEncoder writes plain PHC strings
+	g.Expect(DetectFormat(referencePHCHash)).Should(gomega.Equal(FormatPHC))
+
+	// ids are case sensitive, so this is OpenLDAP's scheme
+	g.Expect(DetectFormat("{ARGON2}" + referencePHCHash)).Should(gomega.Equal(FormatLDAP))
+
+	g.Expect(Compare("{argon2}"+testHash, "my-password")).Should(gomega.Equal(ErrInvalidHash))
+	g.Expect(Compare("{argon2@SpringSecurity_v5_8}", "password")).Should(gomega.Equal(ErrInvalidHash))
+
+	hashedPassword, err := Hasher{Params: Params{Memory: 1024}, Format: FormatSpring}.Hash("my-password")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(hashedPassword).Should(gomega.HavePrefix("{argon2}$argon2id$v=19$m=1024,t=1,p=4$"))
+	g.Expect(Compare(hashedPassword, "my-password")).Should(gomega.Succeed())
+}